@@ -23,6 +23,12 @@ func main() {
 	cfg := config.New()
 
 	flags := []cli.Flag{
+		&cli.StringFlag{
+			EnvVars: []string{envPrefix + "CONFIG"},
+			Name:    "config",
+			Usage:   "`path` to yaml configuration file (flags and env vars take precedence over it)",
+		},
+
 		&cli.StringFlag{
 			Destination: &cfg.Log.Level,
 			EnvVars:     []string{envPrefix + "LOG_LEVEL"},
@@ -54,7 +60,15 @@ func main() {
 		Commands:       commands,
 		DefaultCommand: commands[0].Name,
 
-		Before: func(_ *cli.Context) error {
+		Before: func(clictx *cli.Context) error {
+			// take only log config from the file (the rest is loaded by
+			// commands, after they snapshot the values set via flags)
+			fileCfg := cfg.Copy()
+			if err := loadConfigFile(clictx, fileCfg); err != nil {
+				return err
+			}
+			cfg.Log = fileCfg.Log
+
 			// setup logger
 			l, err := logutils.NewLogger(cfg.Log)
 			if err != nil {
@@ -78,3 +92,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func loadConfigFile(clictx *cli.Context, cfg *config.Config) error {
+	path := clictx.String("config")
+	if path == "" {
+		return nil
+	}
+
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	cfg.Merge(file, clictx.IsSet)
+
+	return nil
+}
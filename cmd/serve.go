@@ -19,14 +19,43 @@ const (
 func CommandServe(cfg *config.Config) *cli.Command {
 	monitorBuilders := &cli.StringSlice{}
 	monitorPeers := &cli.StringSlice{}
+	monitorReference := ""
+
+	var flagsCfg *config.Config // config derived from flags only (for reloads)
 
 	monitorFlags := []cli.Flag{
+		&cli.DurationFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.BlockDelay,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_BLOCK_DELAY"},
+			Name:        categoryMonitor + "-block-delay",
+			Usage:       "`delay` between the quorum of builders reporting new head and the start of monitoring pass (with blocks schedule)",
+			Value:       200 * time.Millisecond,
+		},
+
+		&cli.DurationFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.BlockPollInterval,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_BLOCK_POLL_INTERVAL"},
+			Name:        categoryMonitor + "-block-poll-interval",
+			Usage:       "`interval` at which to poll builders for new heads (with blocks schedule; builders with websocket subscriptions are not polled)",
+			Value:       250 * time.Millisecond,
+		},
+
+		&cli.IntFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.BlockQuorum,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_BLOCK_QUORUM"},
+			Name:        categoryMonitor + "-block-quorum",
+			Usage:       "`count` of builders that must report new head to trigger monitoring pass (with blocks schedule; 0 means all reachable builders)",
+		},
+
 		&cli.StringSliceFlag{
 			Category:    strings.ToUpper(categoryMonitor),
 			Destination: monitorBuilders,
 			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_BUILDERS"},
 			Name:        categoryMonitor + "-builders",
-			Usage:       "list of monitored builder rpc endpoints in the format `name=url`",
+			Usage:       "list of monitored builder rpc endpoints in the format `name=url` (repeat the name to add fallback endpoints)",
 		},
 
 		&cli.DurationFlag{
@@ -34,10 +63,46 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Destination: &cfg.Monitor.Interval,
 			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_INTERVAL"},
 			Name:        categoryMonitor + "-interval",
-			Usage:       "`interval` at which to query builders for their status",
+			Usage:       "`interval` at which to query builders for their status (with blocks schedule: max interval between the passes)",
 			Value:       5 * time.Second,
 		},
 
+		&cli.StringFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.MissingQuorum,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_MISSING_QUORUM"},
+			Name:        categoryMonitor + "-missing-quorum",
+			Usage:       "`quorum` of builders that must have the tx for it to be considered missing on the others: either a count (e.g. 2), or a percentage of builders (e.g. 50%)",
+			Value:       "1",
+		},
+
+		&cli.IntFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.NonceBatchSize,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_NONCE_BATCH_SIZE"},
+			Name:        categoryMonitor + "-nonce-batch-size",
+			Usage:       "max `count` of account nonce lookups per rpc batch request",
+			Value:       100,
+		},
+
+		&cli.IntFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.NonceWorkers,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_NONCE_WORKERS"},
+			Name:        categoryMonitor + "-nonce-workers",
+			Usage:       "max `count` of concurrent nonce lookup batch requests per builder",
+			Value:       4,
+		},
+
+		&cli.StringFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.OverlapPolicy,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_OVERLAP_POLICY"},
+			Name:        categoryMonitor + "-overlap-policy",
+			Usage:       "`policy` for when monitoring pass is still running at the next tick (skip, queue, cancel)",
+			Value:       config.OverlapPolicySkip,
+		},
+
 		&cli.StringSliceFlag{
 			Category:    strings.ToUpper(categoryMonitor),
 			Destination: monitorPeers,
@@ -46,6 +111,39 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Usage:       "list of monitored builder rpc endpoints in the format `label=ip`",
 		},
 
+		&cli.DurationFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.PropagationGrace,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_PROPAGATION_GRACE"},
+			Name:        categoryMonitor + "-propagation-grace",
+			Usage:       "grace `period` for the tx to propagate to the builder since it was first seen elsewhere before it is considered missing",
+		},
+
+		&cli.StringFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &monitorReference,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_REFERENCE"},
+			Name:        categoryMonitor + "-reference",
+			Usage:       "optional rpc endpoint of the node to use as canonical source of confirmed nonces in the format `name=url` (default: builder with the highest head)",
+		},
+
+		&cli.StringFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.Schedule,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_SCHEDULE"},
+			Name:        categoryMonitor + "-schedule",
+			Usage:       "`mode` of scheduling the monitoring passes: at fixed interval, or after builders report new blocks (interval, blocks)",
+			Value:       config.ScheduleInterval,
+		},
+
+		&cli.DurationFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.StuckThreshold,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_STUCK_THRESHOLD"},
+			Name:        categoryMonitor + "-stuck-threshold",
+			Usage:       "`duration` after which the tx that is still pending on the builder is reported as stuck (0 disables the check)",
+		},
+
 		&cli.DurationFlag{
 			Category:    strings.ToUpper(categoryMonitor),
 			Destination: &cfg.Monitor.Timeout,
@@ -54,9 +152,25 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Usage:       "timeout `duration` for rpc queries",
 			Value:       500 * time.Millisecond,
 		},
+
+		&cli.DurationFlag{
+			Category:    strings.ToUpper(categoryMonitor),
+			Destination: &cfg.Monitor.TxpoolSnapshotInterval,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryMonitor) + "_TXPOOL_SNAPSHOT_INTERVAL"},
+			Name:        categoryMonitor + "-txpool-snapshot-interval",
+			Usage:       "min `interval` between txpool_content snapshots of the builders with websocket subscriptions, which txpools are otherwise tracked via the subscriptions (0 means snapshot on every monitoring pass)",
+		},
 	}
 
 	serverFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    strings.ToUpper(categoryServer),
+			Destination: &cfg.Server.AdminToken,
+			EnvVars:     []string{envPrefix + strings.ToUpper(categoryServer) + "_ADMIN_TOKEN"},
+			Name:        categoryServer + "-admin-token",
+			Usage:       "bearer `token` for the admin api (e.g. POST /api/v1/reload); the admin api is disabled when empty",
+		},
+
 		&cli.StringFlag{
 			Category:    strings.ToUpper(categoryServer),
 			Destination: &cfg.Server.ListenAddress,
@@ -77,14 +191,51 @@ func CommandServe(cfg *config.Config) *cli.Command {
 		Usage: "run bmonitor server",
 		Flags: flags,
 
-		Before: func(_ *cli.Context) error {
-			cfg.Monitor.Builders = monitorBuilders.Value()
+		Before: func(clictx *cli.Context) error {
+			cfg.Monitor.Builders = make([]*config.Builder, 0, len(monitorBuilders.Value()))
+			for _, b := range monitorBuilders.Value() {
+				builder, err := config.ParseBuilder(b)
+				if err != nil {
+					return err
+				}
+				// repeated names add the fallback endpoints of the builder
+				idx := slices.IndexFunc(cfg.Monitor.Builders, func(b *config.Builder) bool {
+					return b.Name == builder.Name
+				})
+				if idx >= 0 {
+					cfg.Monitor.Builders[idx].URLs = append(cfg.Monitor.Builders[idx].URLs, builder.URL)
+					continue
+				}
+				cfg.Monitor.Builders = append(cfg.Monitor.Builders, builder)
+			}
+			if monitorReference != "" {
+				reference, err := config.ParseBuilder(monitorReference)
+				if err != nil {
+					return err
+				}
+				cfg.Monitor.Reference = reference
+			}
 			cfg.Monitor.Peers = monitorPeers.Value()
+			flagsCfg = cfg.Copy()
+			if err := loadConfigFile(clictx, cfg); err != nil {
+				return err
+			}
 			return cfg.Validate()
 		},
 
-		Action: func(_ *cli.Context) error {
-			s, err := server.New(cfg)
+		Action: func(clictx *cli.Context) error {
+			reload := func() (*config.Config, error) {
+				cfg := flagsCfg.Copy()
+				if err := loadConfigFile(clictx, cfg); err != nil {
+					return nil, err
+				}
+				if err := cfg.Validate(); err != nil {
+					return nil, err
+				}
+				return cfg, nil
+			}
+
+			s, err := server.New(cfg, reload)
 			if err != nil {
 				return err
 			}
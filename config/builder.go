@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flashbots/bmonitor/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Builder struct {
+	Name            string            `yaml:"name"`
+	URL             string            `yaml:"url"`
+	URLs            []string          `yaml:"urls"`
+	BearerTokenFile string            `yaml:"bearer_token_file"`
+	Checks          []string          `yaml:"checks"`
+	Headers         map[string]string `yaml:"headers"`
+	JWTSecretFile   string            `yaml:"jwt_secret_file"`
+	Labels          map[string]string `yaml:"labels"`
+	Timeout         time.Duration     `yaml:"timeout"`
+	TLS             *TLS              `yaml:"tls"`
+	WSURL           string            `yaml:"ws_url"`
+}
+
+const (
+	CheckHead   = "head"
+	CheckPeers  = "peers"
+	CheckTxpool = "txpool"
+)
+
+var (
+	errBuilderInvalidAuth    = errors.New("invalid builder auth (only one of `bearer_token_file`, `jwt_secret_file`, or `Authorization` header can be used)")
+	errBuilderInvalidCheck   = errors.New("invalid builder check (must be one of `head`, `peers`, `txpool`)")
+	errBuilderInvalidFormat  = errors.New("invalid builder format (must be `name=url`)")
+	errBuilderInvalidHeader  = errors.New("invalid builder http header")
+	errBuilderInvalidLabel   = errors.New("invalid builder label")
+	errBuilderInvalidName    = errors.New("invalid builder name")
+	errBuilderInvalidURL     = errors.New("invalid builder url")
+	errBuilderInvalidWSURL   = errors.New("invalid builder websocket url (must be ws:// or wss://)")
+	errBuilderInvalidTimeout = errors.New("invalid builder timeout (must be non-negative and up to 1m)")
+	errBuilderInvalidTLS     = errors.New("invalid builder tls config")
+)
+
+var (
+	labelRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// reservedLabels are the metric attributes used by bmonitor itself
+	reservedLabels = []string{
+		"action", "builder", "endpoint", "from", "kind", "label", "le", "method", "other", "reason", "section", "severity", "status", "type",
+	}
+)
+
+// ParseBuilder parses the builder from its `name=url` form.
+func ParseBuilder(str string) (*Builder, error) {
+	name, url, found := strings.Cut(strings.TrimSpace(str), "=")
+	if !found {
+		return nil, fmt.Errorf("%w: %s",
+			errBuilderInvalidFormat, str,
+		)
+	}
+	return &Builder{
+		Name: strings.TrimSpace(name),
+		URL:  strings.TrimSpace(url),
+	}, nil
+}
+
+// UnmarshalYAML accepts both the short `name=url` form of the builder as well
+// as the full one.
+func (cfg *Builder) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var str string
+		if err := value.Decode(&str); err != nil {
+			return err
+		}
+		b, err := ParseBuilder(str)
+		if err != nil {
+			return err
+		}
+		*cfg = *b
+		return nil
+	}
+
+	type plain Builder
+	return value.Decode((*plain)(cfg))
+}
+
+// Endpoints returns the urls of the builder in the order of preference (the
+// one from `url` goes first, followed by the ones from `urls`).
+func (cfg *Builder) Endpoints() []string {
+	res := make([]string, 0, 1+len(cfg.URLs))
+	if cfg.URL != "" {
+		res = append(res, cfg.URL)
+	}
+	return append(res, cfg.URLs...)
+}
+
+// IsEnabled returns true if the check is enabled for the builder (all checks
+// are enabled when none are listed explicitly).
+func (cfg *Builder) IsEnabled(check string) bool {
+	return len(cfg.Checks) == 0 || slices.Contains(cfg.Checks, check)
+}
+
+func (cfg *Builder) Validate() error {
+	if cfg.Name == "" {
+		return fmt.Errorf("%w: must not be empty",
+			errBuilderInvalidName,
+		)
+	}
+
+	errs := make([]error, 0)
+
+	{ // urls
+		endpoints := cfg.Endpoints()
+		if len(endpoints) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s: must not be empty",
+				errBuilderInvalidURL, cfg.Name,
+			))
+		}
+		known := make(map[string]struct{}, len(endpoints))
+		for _, endpoint := range endpoints {
+			if _, err := url.Parse(endpoint); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s: %w",
+					errBuilderInvalidURL, cfg.Name, err,
+				))
+			}
+			if _, duplicate := known[endpoint]; duplicate {
+				errs = append(errs, fmt.Errorf("%w: %s: duplicate url",
+					errBuilderInvalidURL, cfg.Name,
+				))
+			}
+			known[endpoint] = struct{}{}
+		}
+	}
+
+	{ // auth
+		methods := 0
+		if cfg.BearerTokenFile != "" {
+			methods++
+		}
+		if cfg.JWTSecretFile != "" {
+			methods++
+		}
+		for header := range cfg.Headers {
+			if strings.EqualFold(header, "Authorization") {
+				methods++
+			}
+		}
+		if methods > 1 {
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errBuilderInvalidAuth, cfg.Name,
+			))
+		}
+	}
+
+	{ // checks
+		for _, check := range cfg.Checks {
+			switch check {
+			case CheckHead, CheckPeers, CheckTxpool:
+			default:
+				errs = append(errs, fmt.Errorf("%w: %s: %s",
+					errBuilderInvalidCheck, cfg.Name, check,
+				))
+			}
+		}
+	}
+
+	{ // headers
+		for header := range cfg.Headers {
+			if header == "" || strings.ContainsAny(header, ": \t\r\n") {
+				errs = append(errs, fmt.Errorf("%w: %s: %q",
+					errBuilderInvalidHeader, cfg.Name, header,
+				))
+			}
+		}
+	}
+
+	{ // labels
+		for label := range cfg.Labels {
+			if !labelRegex.MatchString(label) {
+				errs = append(errs, fmt.Errorf("%w: %s: %s: must match %s",
+					errBuilderInvalidLabel, cfg.Name, label, labelRegex,
+				))
+			}
+			if slices.Contains(reservedLabels, label) {
+				errs = append(errs, fmt.Errorf("%w: %s: %s: reserved name",
+					errBuilderInvalidLabel, cfg.Name, label,
+				))
+			}
+		}
+	}
+
+	{ // timeout
+		if cfg.Timeout < 0 || cfg.Timeout > time.Minute {
+			errs = append(errs, fmt.Errorf("%w: %s: %s",
+				errBuilderInvalidTimeout, cfg.Name, cfg.Timeout,
+			))
+		}
+	}
+
+	{ // tls
+		if cfg.TLS != nil {
+			if (cfg.TLS.Cert == "") != (cfg.TLS.Key == "") {
+				errs = append(errs, fmt.Errorf("%w: %s: client cert and key must be set together",
+					errBuilderInvalidTLS, cfg.Name,
+				))
+			}
+			if _, known := tlsVersions[cfg.TLS.MinVersion]; cfg.TLS.MinVersion != "" && !known {
+				errs = append(errs, fmt.Errorf("%w: %s: invalid min version (must be one of `1.0`, `1.1`, `1.2`, `1.3`): %s",
+					errBuilderInvalidTLS, cfg.Name, cfg.TLS.MinVersion,
+				))
+			}
+		}
+	}
+
+	{ // ws url
+		if cfg.WSURL != "" {
+			if u, err := url.Parse(cfg.WSURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") {
+				errs = append(errs, fmt.Errorf("%w: %s",
+					errBuilderInvalidWSURL, cfg.Name,
+				))
+			}
+		}
+	}
+
+	return utils.FlattenErrors(errs)
+}
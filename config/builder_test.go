@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseBuilder(t *testing.T) {
+	for _, tc := range []struct {
+		str     string
+		want    *Builder
+		wantErr bool
+	}{
+		{
+			str:  "b0=http://127.0.0.1:8545",
+			want: &Builder{Name: "b0", URL: "http://127.0.0.1:8545"},
+		},
+		{
+			str:  " b0 = http://127.0.0.1:8545 ",
+			want: &Builder{Name: "b0", URL: "http://127.0.0.1:8545"},
+		},
+		{
+			str:  "b0=http://127.0.0.1:8545/?key=a=b",
+			want: &Builder{Name: "b0", URL: "http://127.0.0.1:8545/?key=a=b"},
+		},
+		{
+			str:     "http://127.0.0.1:8545",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.str, func(t *testing.T) {
+			got, err := ParseBuilder(tc.str)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseBuilder(%q) = %+v, want error", tc.str, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseBuilder(%q) = %+v, want %+v", tc.str, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilderUnmarshalYAML(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		yaml    string
+		want    []*Builder
+		wantErr bool
+	}{
+		{
+			name: "short form",
+			yaml: `["b0=http://127.0.0.1:8545"]`,
+			want: []*Builder{{Name: "b0", URL: "http://127.0.0.1:8545"}},
+		},
+		{
+			name: "full form",
+			yaml: `
+- name: b1
+  url: http://127.0.0.1:8546
+  urls: [http://fallback:8545]
+  ws_url: ws://127.0.0.1:8547
+  labels: {region: eu}
+  timeout: 1s
+  checks: [head, txpool]
+  headers: {X-Api-Key: secret}
+`,
+			want: []*Builder{{
+				Name:    "b1",
+				URL:     "http://127.0.0.1:8546",
+				URLs:    []string{"http://fallback:8545"},
+				WSURL:   "ws://127.0.0.1:8547",
+				Labels:  map[string]string{"region": "eu"},
+				Timeout: time.Second,
+				Checks:  []string{CheckHead, CheckTxpool},
+				Headers: map[string]string{"X-Api-Key": "secret"},
+			}},
+		},
+		{
+			name: "mixed forms",
+			yaml: `
+- b0=http://127.0.0.1:8545
+- name: b1
+  url: http://127.0.0.1:8546
+`,
+			want: []*Builder{
+				{Name: "b0", URL: "http://127.0.0.1:8545"},
+				{Name: "b1", URL: "http://127.0.0.1:8546"},
+			},
+		},
+		{
+			name:    "short form without name",
+			yaml:    `["http://127.0.0.1:8545"]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid full form",
+			yaml:    `[{name: [b0]}]`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []*Builder
+			err := yaml.Unmarshal([]byte(tc.yaml), &got)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilderValidateAuth(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cfg     *Builder
+		wantErr bool
+	}{
+		{
+			name: "no auth",
+			cfg:  &Builder{},
+		},
+		{
+			name: "bearer token",
+			cfg:  &Builder{BearerTokenFile: "/token"},
+		},
+		{
+			name: "jwt secret",
+			cfg:  &Builder{JWTSecretFile: "/jwt.hex"},
+		},
+		{
+			name: "authorization header",
+			cfg:  &Builder{Headers: map[string]string{"Authorization": "Basic s3cr3t"}},
+		},
+		{
+			name: "jwt secret with other headers",
+			cfg:  &Builder{JWTSecretFile: "/jwt.hex", Headers: map[string]string{"X-Api-Key": "secret"}},
+		},
+		{
+			name:    "jwt secret and authorization header",
+			cfg:     &Builder{JWTSecretFile: "/jwt.hex", Headers: map[string]string{"authorization": "Basic s3cr3t"}},
+			wantErr: true,
+		},
+		{
+			name:    "bearer token and authorization header",
+			cfg:     &Builder{BearerTokenFile: "/token", Headers: map[string]string{"Authorization": "Bearer s3cr3t"}},
+			wantErr: true,
+		},
+		{
+			name:    "bearer token and jwt secret",
+			cfg:     &Builder{BearerTokenFile: "/token", JWTSecretFile: "/jwt.hex"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Name, tc.cfg.URL = "b0", "http://127.0.0.1:8545"
+
+			err := tc.cfg.Validate()
+			if gotErr := errors.Is(err, errBuilderInvalidAuth); gotErr != tc.wantErr {
+				t.Errorf("Validate() = %v, want auth error: %t", err, tc.wantErr)
+			}
+			if err != nil && !tc.wantErr {
+				t.Errorf("Validate() = %v, want no error", err)
+			}
+			if err != nil && strings.Contains(err.Error(), "s3cr3t") {
+				t.Errorf("Validate() = %v, want no secrets in error", err)
+			}
+		})
+	}
+}
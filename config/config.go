@@ -20,6 +20,17 @@ func New() *Config {
 	}
 }
 
+// Copy returns a copy of the config with sections duplicated (the values
+// inside of the sections are shared).
+func (c *Config) Copy() *Config {
+	log, monitor, server := *c.Log, *c.Monitor, *c.Server
+	return &Config{
+		Log:     &log,
+		Monitor: &monitor,
+		Server:  &server,
+	}
+}
+
 func (c *Config) Validate() error {
 	return validate(c)
 }
@@ -60,6 +71,11 @@ func validate(item interface{}) error {
 			}
 		case reflect.Slice, reflect.Array:
 			for jdx := 0; jdx < field.Len(); jdx++ {
+				if v, ok := field.Index(jdx).Interface().(validatee); ok {
+					if err := v.Validate(); err != nil {
+						errs = append(errs, err)
+					}
+				}
 				if err := validate(field.Index(jdx).Interface()); err != nil {
 					errs = append(errs, err)
 				}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errConfigFailedToLoad = errors.New("failed to load configuration file")
+)
+
+// File is the configuration read from yaml file, along with the names of
+// the flags that correspond to the values present in it.
+type File struct {
+	*Config
+
+	keys map[string]struct{} // e.g. `monitor-block-delay`
+}
+
+// Load reads the configuration from yaml file.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w",
+			errConfigFailedToLoad, err,
+		)
+	}
+
+	cfg := New()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w",
+			errConfigFailedToLoad, path, err,
+		)
+	}
+
+	// the values that are present in the file are told apart from the
+	// missing ones by the keys, as they might be set to zero on purpose
+	sections := make(map[string]map[string]yaml.Node)
+	if err := yaml.Unmarshal(b, &sections); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w",
+			errConfigFailedToLoad, path, err,
+		)
+	}
+
+	keys := make(map[string]struct{})
+	for section, values := range sections {
+		for key := range values {
+			keys[strings.ReplaceAll(section+"-"+key, "_", "-")] = struct{}{}
+		}
+	}
+
+	return &File{Config: cfg, keys: keys}, nil
+}
+
+// Merge copies the values that are present in the configuration file, except
+// for those that were explicitly set by cli flags (or env vars).  The name of
+// the flag that corresponds to the config value is derived from yaml tags of
+// the section and of the value (e.g. `monitor.overlap_policy` =>
+// `monitor-overlap-policy`).
+func (c *Config) Merge(file *File, isSet func(flag string) bool) {
+	dst := reflect.ValueOf(c).Elem()
+	src := reflect.ValueOf(file.Config).Elem()
+
+	for idx := 0; idx < dst.NumField(); idx++ {
+		section := yamlName(dst.Type().Field(idx))
+
+		dstSection, srcSection := dst.Field(idx), src.Field(idx)
+		if srcSection.IsNil() {
+			continue
+		}
+		if dstSection.IsNil() {
+			dstSection.Set(srcSection)
+			continue
+		}
+		dstSection, srcSection = dstSection.Elem(), srcSection.Elem()
+
+		for jdx := 0; jdx < dstSection.NumField(); jdx++ {
+			flag := section + "-" + yamlName(dstSection.Type().Field(jdx))
+			if _, present := file.keys[flag]; !present || isSet(flag) {
+				continue
+			}
+			dstSection.Field(jdx).Set(srcSection.Field(jdx))
+		}
+	}
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return strings.ReplaceAll(name, "_", "-")
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfigMerge(t *testing.T) {
+	flags := func() *Config {
+		cfg := New()
+		cfg.Log.Level = "info"
+		cfg.Monitor.BlockDelay = time.Second
+		cfg.Monitor.Interval = 5 * time.Second
+		cfg.Monitor.NonceBatchSize = 100
+		cfg.Monitor.OverlapPolicy = OverlapPolicySkip
+		cfg.Monitor.Builders = []*Builder{{Name: "flag", URL: "http://flag"}}
+		cfg.Server.ListenAddress = "0.0.0.0:8080"
+		return cfg
+	}
+
+	for _, tc := range []struct {
+		name  string
+		yaml  string
+		isSet []string
+		want  func(cfg *Config)
+	}{
+		{
+			name: "file overrides defaults",
+			yaml: `
+log:
+  level: debug
+monitor:
+  interval: 10s
+  overlap_policy: queue
+`,
+			want: func(cfg *Config) {
+				cfg.Log.Level = "debug"
+				cfg.Monitor.Interval = 10 * time.Second
+				cfg.Monitor.OverlapPolicy = OverlapPolicyQueue
+			},
+		},
+		{
+			name: "explicit flags override file",
+			yaml: `
+log:
+  level: debug
+monitor:
+  interval: 10s
+  overlap_policy: queue
+`,
+			isSet: []string{"log-level", "monitor-overlap-policy"},
+			want: func(cfg *Config) {
+				cfg.Monitor.Interval = 10 * time.Second
+			},
+		},
+		{
+			name: "zero values in file override defaults",
+			yaml: `
+monitor:
+  block_delay: 0s
+  nonce_batch_size: 0
+  overlap_policy: ""
+`,
+			want: func(cfg *Config) {
+				cfg.Monitor.BlockDelay = 0
+				cfg.Monitor.NonceBatchSize = 0
+				cfg.Monitor.OverlapPolicy = ""
+			},
+		},
+		{
+			name: "missing values in file are ignored",
+			yaml: `
+monitor:
+  interval: 5s
+server: {}
+`,
+			want: func(*Config) {},
+		},
+		{
+			name: "missing sections in file are ignored",
+			yaml: "{}",
+			want: func(*Config) {},
+		},
+		{
+			name: "lists from file replace default ones",
+			yaml: `
+monitor:
+  builders:
+    - name: file
+      url: http://file
+  peers:
+    - peer=10.0.0.1
+`,
+			want: func(cfg *Config) {
+				cfg.Monitor.Builders = []*Builder{{Name: "file", URL: "http://file"}}
+				cfg.Monitor.Peers = []string{"peer=10.0.0.1"}
+			},
+		},
+		{
+			name: "lists set via flags are kept",
+			yaml: `
+monitor:
+  builders:
+    - name: file
+      url: http://file
+`,
+			isSet: []string{"monitor-builders"},
+			want:  func(*Config) {},
+		},
+		{
+			name: "pointer values from file",
+			yaml: `
+monitor:
+  reference:
+    name: ref
+    url: http://ref
+`,
+			want: func(cfg *Config) {
+				cfg.Monitor.Reference = &Builder{Name: "ref", URL: "http://ref"}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			file, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := flags()
+			cfg.Merge(file, func(flag string) bool {
+				return slices.Contains(tc.isSet, flag)
+			})
+
+			want := flags()
+			tc.want(want)
+
+			for _, section := range []struct {
+				name      string
+				got, want any
+			}{
+				{"log", cfg.Log, want.Log},
+				{"monitor", cfg.Monitor, want.Monitor},
+				{"server", cfg.Server, want.Server},
+			} {
+				if !reflect.DeepEqual(section.got, section.want) {
+					t.Errorf("%s = %+v, want %+v", section.name, section.got, section.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		yaml    string
+		wantErr bool
+		want    *Config
+	}{
+		{
+			name: "sections",
+			yaml: `
+log:
+  level: debug
+monitor:
+  interval: 10s
+  missing_quorum: 50%
+server:
+  listen_address: 127.0.0.1:8080
+`,
+			want: &Config{
+				Log:     &Log{Level: "debug"},
+				Monitor: &Monitor{Interval: 10 * time.Second, MissingQuorum: "50%"},
+				Server:  &Server{ListenAddress: "127.0.0.1:8080"},
+			},
+		},
+		{
+			name:    "unknown field",
+			yaml:    "monitor:\n  intervl: 10s\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed yaml",
+			yaml:    "monitor: [",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Load() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Config, tc.want) {
+				t.Errorf("Load() = %+v, want %+v", got.Config, tc.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("Load() of missing file succeeded, want error")
+	}
+}
@@ -3,8 +3,9 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
-	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,33 +13,87 @@ import (
 )
 
 type Monitor struct {
-	Builders []string      `yaml:"builders"`
-	Interval time.Duration `yaml:"interval"`
-	Peers    []string      `yaml:"peers"`
-	Timeout  time.Duration `yaml:"timeout"`
+	BlockDelay             time.Duration `yaml:"block_delay"`
+	BlockPollInterval      time.Duration `yaml:"block_poll_interval"`
+	BlockQuorum            int           `yaml:"block_quorum"`
+	Builders               []*Builder    `yaml:"builders"`
+	Interval               time.Duration `yaml:"interval"`
+	MissingQuorum          string        `yaml:"missing_quorum"`
+	NonceBatchSize         int           `yaml:"nonce_batch_size"`
+	NonceWorkers           int           `yaml:"nonce_workers"`
+	OverlapPolicy          string        `yaml:"overlap_policy"`
+	Peers                  []string      `yaml:"peers"`
+	PropagationGrace       time.Duration `yaml:"propagation_grace"`
+	Reference              *Builder      `yaml:"reference"`
+	Schedule               string        `yaml:"schedule"`
+	StuckThreshold         time.Duration `yaml:"stuck_threshold"`
+	Timeout                time.Duration `yaml:"timeout"`
+	TxpoolSnapshotInterval time.Duration `yaml:"txpool_snapshot_interval"`
 }
 
+const (
+	OverlapPolicyCancel = "cancel"
+	OverlapPolicyQueue  = "queue"
+	OverlapPolicySkip   = "skip"
+
+	ScheduleBlocks   = "blocks"
+	ScheduleInterval = "interval"
+)
+
 var (
-	errMonitorInvalidBuilder  = errors.New("invalid builder")
-	errMonitorInvalidInterval = errors.New("invalid monitoring interval (must be non-zero and up to 1h)")
-	errMonitorInvalidPeer     = errors.New("invalid peer")
-	errMonitorInvalidTimeout  = errors.New("invalid monitoring timeout (must be non-zero, up to 1m, and less than monitoring interval)")
+	errMonitorInvalidBlockDelay     = errors.New("invalid block delay (must be non-negative and less than monitoring interval)")
+	errMonitorInvalidBlockPoll      = errors.New("invalid block poll interval (must be non-zero and less than monitoring interval)")
+	errMonitorInvalidBlockQuorum    = errors.New("invalid block quorum (must be non-negative and not more than the count of builders)")
+	errMonitorInvalidBuilder        = errors.New("invalid builder")
+	errMonitorInvalidInterval       = errors.New("invalid monitoring interval (must be non-zero and up to 1h)")
+	errMonitorInvalidMissingQuorum  = errors.New("invalid missing tx quorum (must be either a positive count of builders not more than their total, or a percentage of them in (0%, 100%])")
+	errMonitorInvalidNonceBatchSize = errors.New("invalid nonce batch size (must be non-zero and up to 1000)")
+	errMonitorInvalidNonceWorkers   = errors.New("invalid count of nonce workers (must be non-zero and up to 64)")
+	errMonitorInvalidOverlap        = errors.New("invalid overlap policy (must be one of `skip`, `queue`, `cancel`)")
+	errMonitorInvalidPeer           = errors.New("invalid peer")
+	errMonitorInvalidPropagation    = errors.New("invalid propagation grace period (must be non-negative and up to 1h)")
+	errMonitorInvalidReference      = errors.New("invalid reference node")
+	errMonitorInvalidSchedule       = errors.New("invalid schedule (must be one of `interval`, `blocks`)")
+	errMonitorInvalidStuckThreshold = errors.New("invalid stuck tx threshold (must be non-negative)")
+	errMonitorInvalidTimeout        = errors.New("invalid monitoring timeout (must be non-zero, up to 1m, and less than monitoring interval)")
+	errMonitorInvalidTxpoolSnapshot = errors.New("invalid txpool snapshot interval (must be non-negative and up to 1h)")
 )
 
 func (cfg *Monitor) Validate() error {
 	errs := make([]error, 0)
 
+	{ // block schedule
+		if cfg.Schedule == ScheduleBlocks {
+			if cfg.BlockDelay < 0 || cfg.BlockDelay >= cfg.Interval {
+				errs = append(errs, fmt.Errorf("%w: %s",
+					errMonitorInvalidBlockDelay, cfg.BlockDelay,
+				))
+			}
+			if cfg.BlockPollInterval <= 0 || cfg.BlockPollInterval >= cfg.Interval {
+				errs = append(errs, fmt.Errorf("%w: %s",
+					errMonitorInvalidBlockPoll, cfg.BlockPollInterval,
+				))
+			}
+			if cfg.BlockQuorum < 0 || cfg.BlockQuorum > len(cfg.Builders) {
+				errs = append(errs, fmt.Errorf("%w: %d",
+					errMonitorInvalidBlockQuorum, cfg.BlockQuorum,
+				))
+			}
+		}
+	}
+
 	{ // builders
+		names := make(map[string]struct{}, len(cfg.Builders))
 		for _, builder := range cfg.Builders {
-			parts := strings.Split(strings.TrimSpace(builder), "=")
-			if len(parts) != 2 {
-				errs = append(errs, fmt.Errorf("%w: invalid format (must be `name=url`): %s",
-					errMonitorInvalidBuilder, builder,
+			if _, known := names[builder.Name]; known {
+				errs = append(errs, fmt.Errorf("%w: %s: duplicate name",
+					errMonitorInvalidBuilder, builder.Name,
 				))
 			}
-			if _, err := url.Parse(strings.TrimSpace(parts[1])); err != nil {
-				errs = append(errs, fmt.Errorf("%w: %s: invalid url: %w",
-					errMonitorInvalidBuilder, builder, err,
+			names[builder.Name] = struct{}{}
+			if builder.Timeout >= cfg.Interval {
+				errs = append(errs, fmt.Errorf("%w: %s: timeout %s >= %s",
+					errMonitorInvalidBuilder, builder.Name, builder.Timeout, cfg.Interval,
 				))
 			}
 		}
@@ -52,6 +107,41 @@ func (cfg *Monitor) Validate() error {
 		}
 	}
 
+	{ // missing quorum
+		count, _, err := parseQuorum(cfg.MissingQuorum)
+		if err != nil || (len(cfg.Builders) > 0 && count > len(cfg.Builders)) {
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidMissingQuorum, cfg.MissingQuorum,
+			))
+		}
+	}
+
+	{ // nonce batch size
+		if cfg.NonceBatchSize <= 0 || cfg.NonceBatchSize > 1000 {
+			errs = append(errs, fmt.Errorf("%w: %d",
+				errMonitorInvalidNonceBatchSize, cfg.NonceBatchSize,
+			))
+		}
+	}
+
+	{ // nonce workers
+		if cfg.NonceWorkers <= 0 || cfg.NonceWorkers > 64 {
+			errs = append(errs, fmt.Errorf("%w: %d",
+				errMonitorInvalidNonceWorkers, cfg.NonceWorkers,
+			))
+		}
+	}
+
+	{ // overlap policy
+		switch cfg.OverlapPolicy {
+		case OverlapPolicyCancel, OverlapPolicyQueue, OverlapPolicySkip:
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidOverlap, cfg.OverlapPolicy,
+			))
+		}
+	}
+
 	{ // peers
 		for _, peer := range cfg.Peers {
 			parts := strings.Split(peer, "=")
@@ -59,6 +149,7 @@ func (cfg *Monitor) Validate() error {
 				errs = append(errs, fmt.Errorf("%w: %s: must be in format 'label=ip.add.re.ss'",
 					errMonitorInvalidPeer, peer,
 				))
+				continue
 			}
 			ip := strings.TrimSpace(parts[1])
 			if net.ParseIP(ip) == nil {
@@ -69,6 +160,44 @@ func (cfg *Monitor) Validate() error {
 		}
 	}
 
+	{ // propagation grace
+		if cfg.PropagationGrace < 0 || cfg.PropagationGrace > time.Hour {
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidPropagation, cfg.PropagationGrace,
+			))
+		}
+	}
+
+	{ // reference
+		if cfg.Reference != nil {
+			for _, builder := range cfg.Builders {
+				if builder.Name == cfg.Reference.Name {
+					errs = append(errs, fmt.Errorf("%w: %s: name is already used by a builder",
+						errMonitorInvalidReference, cfg.Reference.Name,
+					))
+				}
+			}
+		}
+	}
+
+	{ // schedule
+		switch cfg.Schedule {
+		case ScheduleBlocks, ScheduleInterval:
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidSchedule, cfg.Schedule,
+			))
+		}
+	}
+
+	{ // stuck threshold
+		if cfg.StuckThreshold < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidStuckThreshold, cfg.StuckThreshold,
+			))
+		}
+	}
+
 	{ // timeout
 		if cfg.Timeout <= 0 {
 			errs = append(errs, fmt.Errorf("%w: %s <= 0",
@@ -87,5 +216,55 @@ func (cfg *Monitor) Validate() error {
 		}
 	}
 
+	{ // txpool snapshot interval
+		if cfg.TxpoolSnapshotInterval < 0 || cfg.TxpoolSnapshotInterval > time.Hour {
+			errs = append(errs, fmt.Errorf("%w: %s",
+				errMonitorInvalidTxpoolSnapshot, cfg.TxpoolSnapshotInterval,
+			))
+		}
+	}
+
 	return utils.FlattenErrors(errs)
 }
+
+// MissingQuorumOf returns the count of builders (out of the given total) that
+// must have the tx for it to be considered missing on the others.
+func (cfg *Monitor) MissingQuorumOf(total int) int {
+	count, percentage, err := parseQuorum(cfg.MissingQuorum)
+	if err != nil {
+		return 1
+	}
+	if percentage != 0 {
+		return max(1, int(math.Ceil(float64(total)*percentage/100)))
+	}
+	return count
+}
+
+// parseQuorum parses either an absolute count (e.g. `2`), or a percentage
+// (e.g. `50%`).
+func parseQuorum(quorum string) (int, float64, error) {
+	quorum = strings.TrimSpace(quorum)
+	if quorum == "" {
+		return 1, 0, nil
+	}
+
+	if str, isPercentage := strings.CutSuffix(quorum, "%"); isPercentage {
+		percentage, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if percentage <= 0 || percentage > 100 {
+			return 0, 0, fmt.Errorf("out of range: %s", quorum)
+		}
+		return 0, percentage, nil
+	}
+
+	count, err := strconv.Atoi(quorum)
+	if err != nil {
+		return 0, 0, err
+	}
+	if count <= 0 {
+		return 0, 0, fmt.Errorf("out of range: %s", quorum)
+	}
+	return count, 0, nil
+}
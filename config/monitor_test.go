@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseQuorum(t *testing.T) {
+	for _, tc := range []struct {
+		quorum         string
+		wantCount      int
+		wantPercentage float64
+		wantErr        bool
+	}{
+		{quorum: "", wantCount: 1},
+		{quorum: "1", wantCount: 1},
+		{quorum: " 3 ", wantCount: 3},
+		{quorum: "50%", wantPercentage: 50},
+		{quorum: "12.5 %", wantPercentage: 12.5},
+		{quorum: "100%", wantPercentage: 100},
+		{quorum: "0", wantErr: true},
+		{quorum: "-1", wantErr: true},
+		{quorum: "0%", wantErr: true},
+		{quorum: "101%", wantErr: true},
+		{quorum: "half", wantErr: true},
+		{quorum: "%", wantErr: true},
+		{quorum: "1.5", wantErr: true},
+	} {
+		t.Run(tc.quorum, func(t *testing.T) {
+			count, percentage, err := parseQuorum(tc.quorum)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseQuorum(%q) = %d, %f, want error", tc.quorum, count, percentage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tc.wantCount || percentage != tc.wantPercentage {
+				t.Errorf("parseQuorum(%q) = %d, %f, want %d, %f",
+					tc.quorum, count, percentage, tc.wantCount, tc.wantPercentage,
+				)
+			}
+		})
+	}
+}
+
+func TestMissingQuorumOf(t *testing.T) {
+	for _, tc := range []struct {
+		quorum string
+		total  int
+		want   int
+	}{
+		{quorum: "", total: 5, want: 1},
+		{quorum: "2", total: 5, want: 2},
+		{quorum: "2", total: 1, want: 2},
+		{quorum: "50%", total: 4, want: 2},
+		{quorum: "50%", total: 5, want: 3},
+		{quorum: "100%", total: 3, want: 3},
+		{quorum: "10%", total: 3, want: 1},
+		{quorum: "10%", total: 0, want: 1},
+		{quorum: "invalid", total: 5, want: 1},
+	} {
+		t.Run(tc.quorum, func(t *testing.T) {
+			cfg := &Monitor{MissingQuorum: tc.quorum}
+			if got := cfg.MissingQuorumOf(tc.total); got != tc.want {
+				t.Errorf("MissingQuorumOf(%d) with %q = %d, want %d", tc.total, tc.quorum, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMonitorValidateMissingQuorum(t *testing.T) {
+	builders := []*Builder{
+		{Name: "b0", URL: "http://127.0.0.1:8545"},
+		{Name: "b1", URL: "http://127.0.0.1:8546"},
+	}
+
+	for _, tc := range []struct {
+		quorum   string
+		builders []*Builder
+		wantErr  bool
+	}{
+		{quorum: "1", builders: builders},
+		{quorum: "2", builders: builders},
+		{quorum: "100%", builders: builders},
+		{quorum: "3", builders: builders, wantErr: true},
+		{quorum: "1", builders: nil},
+		{quorum: "0", builders: builders, wantErr: true},
+		{quorum: "150%", builders: builders, wantErr: true},
+	} {
+		t.Run(tc.quorum, func(t *testing.T) {
+			cfg := &Monitor{
+				Builders:       tc.builders,
+				Interval:       5 * time.Second,
+				MissingQuorum:  tc.quorum,
+				NonceBatchSize: 100,
+				NonceWorkers:   4,
+				OverlapPolicy:  OverlapPolicySkip,
+				Schedule:       ScheduleInterval,
+				Timeout:        time.Second,
+			}
+			err := cfg.Validate()
+			if isQuorumErr := errors.Is(err, errMonitorInvalidMissingQuorum); isQuorumErr != tc.wantErr {
+				t.Errorf("Validate() with quorum %q = %v, want quorum error: %t", tc.quorum, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMonitorValidatePeers(t *testing.T) {
+	for _, tc := range []struct {
+		peer    string
+		wantErr bool
+	}{
+		{peer: "p0=10.0.0.1"},
+		{peer: " p0 = 10.0.0.1 "},
+		{peer: "p0=::1"},
+		{peer: "p0", wantErr: true},
+		{peer: "p0=10.0.0.1=10.0.0.2", wantErr: true},
+		{peer: "p0=not-an-ip", wantErr: true},
+	} {
+		t.Run(tc.peer, func(t *testing.T) {
+			cfg := &Monitor{
+				Interval:       5 * time.Second,
+				MissingQuorum:  "1",
+				NonceBatchSize: 100,
+				NonceWorkers:   4,
+				OverlapPolicy:  OverlapPolicySkip,
+				Peers:          []string{tc.peer},
+				Schedule:       ScheduleInterval,
+				Timeout:        time.Second,
+			}
+			err := cfg.Validate()
+			if isPeerErr := errors.Is(err, errMonitorInvalidPeer); isPeerErr != tc.wantErr {
+				t.Errorf("Validate() with peer %q = %v, want peer error: %t", tc.peer, err, tc.wantErr)
+			}
+		})
+	}
+}
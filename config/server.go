@@ -7,6 +7,7 @@ import (
 )
 
 type Server struct {
+	AdminToken    string `yaml:"admin_token"`
 	ListenAddress string `yaml:"listen_address"`
 }
 
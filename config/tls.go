@@ -0,0 +1,25 @@
+package config
+
+import (
+	"crypto/tls"
+)
+
+type TLS struct {
+	CA         string `yaml:"ca"`
+	Cert       string `yaml:"cert"`
+	Key        string `yaml:"key"`
+	MinVersion string `yaml:"min_version"`
+	ServerName string `yaml:"server_name"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Version returns the minimum tls version (or 0 if it is not configured).
+func (cfg *TLS) Version() uint16 {
+	return tlsVersions[cfg.MinVersion]
+}
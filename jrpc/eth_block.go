@@ -0,0 +1,10 @@
+package jrpc
+
+import "github.com/ethereum/go-ethereum/common/hexutil"
+
+type EthBlock struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       string         `json:"hash"`
+	ParentHash string         `json:"parentHash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+}
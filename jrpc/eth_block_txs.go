@@ -0,0 +1,8 @@
+package jrpc
+
+// EthBlockTxs is the block together with its transactions (as returned by
+// eth_getBlockByHash with full transactions).
+type EthBlockTxs struct {
+	Hash         string              `json:"hash"`
+	Transactions []*TxpoolContent_Tx `json:"transactions"`
+}
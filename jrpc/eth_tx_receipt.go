@@ -0,0 +1,9 @@
+package jrpc
+
+import "github.com/ethereum/go-ethereum/common/hexutil"
+
+type EthTxReceipt struct {
+	TxHash      string         `json:"transactionHash"`
+	BlockHash   string         `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+}
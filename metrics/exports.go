@@ -5,9 +5,41 @@ import (
 )
 
 var (
-	PeersCount                otelapi.Int64Gauge
-	TxpoolDuplicateNonceCount otelapi.Int64Counter
-	TxpoolNonceGapsLength     otelapi.Int64Gauge
-	TxpoolMissingTxCount      otelapi.Int64Gauge
-	TxpoolUnknownTxCount      otelapi.Int64Gauge
+	AccountNonceMismatchCount        *Int64Gauge
+	BuilderEndpointActive            *Int64Gauge
+	BuilderLastUpdated               *Int64Gauge
+	BuilderStreamAnnouncedTxCount    otelapi.Int64Counter
+	BuilderStreamConnected           *Int64Gauge
+	BuilderUp                        *Int64Gauge
+	ChainForkCount                   otelapi.Int64Counter
+	ChainHeadLagBlocks               *Int64Gauge
+	ChainHeadLagSeconds              *Int64Gauge
+	ChainHeadNumber                  *Int64Gauge
+	FindingsCount                    *Int64Gauge
+	MonitorLastPassTimestamp         *Int64Gauge
+	MonitorLateTicksCount            otelapi.Int64Counter
+	MonitorPassDuration              otelapi.Float64Histogram
+	PeersCount                       *Int64Gauge
+	ReorgDepth                       otelapi.Int64Histogram
+	RPCCallDuration                  otelapi.Float64Histogram
+	RPCCallsCount                    otelapi.Int64Counter
+	RPCErrorsCount                   otelapi.Int64Counter
+	RPCResponseSize                  otelapi.Int64Histogram
+	TLSCertificateExpiry             *Int64Gauge
+	TxPropagationDelay               otelapi.Float64Histogram
+	TxpoolDivergenceTxCount          *Int64Gauge
+	TxpoolDuplicateNonceCount        otelapi.Int64Counter
+	TxpoolIncludedTxCount            *Int64Gauge
+	TxpoolNonceGapsLength            *Int64Gauge
+	TxpoolMissingTxCount             *Int64Gauge
+	TxpoolPendingQueuedMismatchCount *Int64Gauge
+	TxpoolPendingTxAge               otelapi.Float64Histogram
+	TxpoolPendingTxCount             *Int64Gauge
+	TxpoolQueuedTxCount              *Int64Gauge
+	TxpoolReplacedTxCount            *Int64Gauge
+	TxpoolSimilarity                 *Float64Gauge
+	TxpoolSingletonTxCount           *Int64Gauge
+	TxpoolStaleTxCount               *Int64Gauge
+	TxpoolStuckTxCount               *Int64Gauge
+	TxpoolUnknownTxCount             *Int64Gauge
 )
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+)
+
+// Int64Gauge keeps the last recorded value of each of its series and reports
+// them on collection.  Unlike synchronous otel gauge, it allows to forget the
+// series (e.g. when the data they were derived from becomes unavailable), so
+// that stale values do not linger in the exported metrics.
+type Int64Gauge struct {
+	gauge[int64]
+}
+
+// Float64Gauge is the same as Int64Gauge, but for float values.
+type Float64Gauge struct {
+	gauge[float64]
+}
+
+type gauge[T int64 | float64] struct {
+	mx     sync.Mutex
+	series map[attribute.Distinct]gaugeSeries[T]
+}
+
+type gaugeSeries[T int64 | float64] struct {
+	attributes attribute.Set
+	value      T
+}
+
+var (
+	gauges   = make([]interface{ Forget(...attribute.KeyValue) }, 0)
+	gaugesMx sync.Mutex
+)
+
+func newInt64Gauge() *Int64Gauge {
+	g := &Int64Gauge{gauge[int64]{
+		series: make(map[attribute.Distinct]gaugeSeries[int64]),
+	}}
+
+	gaugesMx.Lock()
+	defer gaugesMx.Unlock()
+	gauges = append(gauges, g)
+
+	return g
+}
+
+func newFloat64Gauge() *Float64Gauge {
+	g := &Float64Gauge{gauge[float64]{
+		series: make(map[attribute.Distinct]gaugeSeries[float64]),
+	}}
+
+	gaugesMx.Lock()
+	defer gaugesMx.Unlock()
+	gauges = append(gauges, g)
+
+	return g
+}
+
+// Forget removes the series that have every one of the given attributes from
+// all gauges (counters and histograms can not forget their series).
+func Forget(attributes ...attribute.KeyValue) {
+	gaugesMx.Lock()
+	defer gaugesMx.Unlock()
+
+	for _, g := range gauges {
+		g.Forget(attributes...)
+	}
+}
+
+// Record sets the value of the series identified by the attributes.
+func (g *gauge[T]) Record(_ context.Context, value T, options ...otelapi.RecordOption) {
+	attributes := otelapi.NewRecordConfig(options).Attributes()
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	g.series[attributes.Equivalent()] = gaugeSeries[T]{
+		attributes: attributes,
+		value:      value,
+	}
+}
+
+// Forget removes all series that have every one of the given attributes.
+func (g *gauge[T]) Forget(attributes ...attribute.KeyValue) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	for key, series := range g.series {
+		if hasAttributes(series.attributes, attributes) {
+			delete(g.series, key)
+		}
+	}
+}
+
+func (g *gauge[T]) each(fn func(value T, attributes attribute.Set)) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	for _, series := range g.series {
+		fn(series.value, series.attributes)
+	}
+}
+
+func (g *Int64Gauge) observe(_ context.Context, o otelapi.Int64Observer) error {
+	g.each(func(value int64, attributes attribute.Set) {
+		o.Observe(value, otelapi.WithAttributeSet(attributes))
+	})
+	return nil
+}
+
+func (g *Float64Gauge) observe(_ context.Context, o otelapi.Float64Observer) error {
+	g.each(func(value float64, attributes attribute.Set) {
+		o.Observe(value, otelapi.WithAttributeSet(attributes))
+	})
+	return nil
+}
+
+func hasAttributes(set attribute.Set, attributes []attribute.KeyValue) bool {
+	for _, kv := range attributes {
+		if value, present := set.Value(kv.Key); !present || value != kv.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -20,10 +20,42 @@ var (
 func Setup(ctx context.Context) error {
 	for _, setup := range []func(context.Context) error{
 		setupMeter, // must come first
+		setupAccountNonceMismatchCount,
+		setupBuilderEndpointActive,
+		setupBuilderLastUpdated,
+		setupBuilderStreamAnnouncedTxCount,
+		setupBuilderStreamConnected,
+		setupBuilderUp,
+		setupChainForkCount,
+		setupChainHeadLagBlocks,
+		setupChainHeadLagSeconds,
+		setupChainHeadNumber,
+		setupFindingsCount,
+		setupMonitorLastPassTimestamp,
+		setupMonitorLateTicksCount,
+		setupMonitorPassDuration,
 		setupPeersCount,
+		setupReorgDepth,
+		setupRPCCallDuration,
+		setupRPCCallsCount,
+		setupRPCErrorsCount,
+		setupRPCResponseSize,
+		setupTLSCertificateExpiry,
+		setupTxPropagationDelay,
+		setupTxpoolDivergenceTxCount,
 		setupTxpoolDuplicateNonceCount,
+		setupTxpoolIncludedTxCount,
 		setupTxpoolNonceGapsLength,
 		setupTxpoolMissingTxCount,
+		setupTxpoolPendingQueuedMismatchCount,
+		setupTxpoolPendingTxAge,
+		setupTxpoolPendingTxCount,
+		setupTxpoolQueuedTxCount,
+		setupTxpoolReplacedTxCount,
+		setupTxpoolSimilarity,
+		setupTxpoolSingletonTxCount,
+		setupTxpoolStaleTxCount,
+		setupTxpoolStuckTxCount,
 		setupTxpoolUnknownTxCount,
 	} {
 		if err := setup(ctx); err != nil {
@@ -42,6 +74,7 @@ func setupMeter(ctx context.Context) error {
 
 	exporter, err := prometheus.New(
 		prometheus.WithNamespace(metricsNamespace),
+		prometheus.WithRegisterer(registry),
 		prometheus.WithoutScopeInfo(),
 	)
 	if err != nil {
@@ -58,14 +91,274 @@ func setupMeter(ctx context.Context) error {
 	return nil
 }
 
+func setupAccountNonceMismatchCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("account_nonce_mismatch_count",
+		otelapi.WithDescription("count of accounts which confirmed nonce according to the builder differs from the canonical one"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	AccountNonceMismatchCount = m
+	return nil
+}
+
+func setupBuilderEndpointActive(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("builder_endpoint_active",
+		otelapi.WithDescription("whether the endpoint is the one currently used to reach the builder (1) or not (0)"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	BuilderEndpointActive = m
+	return nil
+}
+
+func setupBuilderLastUpdated(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("builder_last_updated_timestamp",
+		otelapi.WithDescription("unix timestamp of when the section (head, peers, txpool) of builder's status was last fetched successfully"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	BuilderLastUpdated = m
+	return nil
+}
+
+func setupBuilderStreamAnnouncedTxCount(ctx context.Context) error {
+	m, err := meter.Int64Counter("builder_stream_announced_tx_count",
+		otelapi.WithDescription("count of pending txs announced by the builder via websocket subscription"),
+	)
+	if err != nil {
+		return err
+	}
+	BuilderStreamAnnouncedTxCount = m
+	return nil
+}
+
+func setupBuilderStreamConnected(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("builder_stream_connected",
+		otelapi.WithDescription("whether the websocket subscriptions to the builder are up (1) or not (0)"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	BuilderStreamConnected = m
+	return nil
+}
+
+func setupBuilderUp(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("builder_up",
+		otelapi.WithDescription("whether the builder was reachable during the last monitoring pass (1) or some rpc call failed to reach it (0)"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	BuilderUp = m
+	return nil
+}
+
+func setupChainForkCount(ctx context.Context) error {
+	m, err := meter.Int64Counter("chain_fork_count",
+		otelapi.WithDescription("count of heights at which builders reported different block hashes"),
+	)
+	if err != nil {
+		return err
+	}
+	ChainForkCount = m
+	return nil
+}
+
+func setupChainHeadLagBlocks(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("chain_head_lag_blocks",
+		otelapi.WithDescription("count of blocks by which builder's head lags behind the highest head seen"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	ChainHeadLagBlocks = m
+	return nil
+}
+
+func setupChainHeadLagSeconds(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("chain_head_lag_seconds",
+		otelapi.WithDescription("difference between timestamps of the highest head seen and builder's head"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	ChainHeadLagSeconds = m
+	return nil
+}
+
+func setupChainHeadNumber(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("chain_head_number",
+		otelapi.WithDescription("number of the latest block known to the builder"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	ChainHeadNumber = m
+	return nil
+}
+
+func setupFindingsCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("findings_count",
+		otelapi.WithDescription("count of findings reported by the last monitoring pass"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	FindingsCount = m
+	return nil
+}
+
+func setupMonitorLastPassTimestamp(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("monitor_last_pass_timestamp",
+		otelapi.WithDescription("unix timestamp of when the last monitoring pass has completed"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	MonitorLastPassTimestamp = m
+	return nil
+}
+
+func setupMonitorLateTicksCount(ctx context.Context) error {
+	m, err := meter.Int64Counter("monitor_late_ticks_count",
+		otelapi.WithDescription("count of ticks that came while the previous monitoring pass was still running"),
+	)
+	if err != nil {
+		return err
+	}
+	MonitorLateTicksCount = m
+	return nil
+}
+
+func setupMonitorPassDuration(ctx context.Context) error {
+	m, err := meter.Float64Histogram("monitor_pass_duration_seconds",
+		otelapi.WithDescription("duration of the monitoring passes"),
+		otelapi.WithExplicitBucketBoundaries(.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60),
+	)
+	if err != nil {
+		return err
+	}
+	MonitorPassDuration = m
+	return nil
+}
+
 func setupPeersCount(ctx context.Context) error {
-	m, err := meter.Int64Gauge("peers_count",
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("peers_count",
 		otelapi.WithDescription("count of connected peers"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	PeersCount = m
+	return nil
+}
+
+func setupReorgDepth(ctx context.Context) error {
+	m, err := meter.Int64Histogram("reorg_depth",
+		otelapi.WithDescription("depth of the reorgs observed on the builder"),
+		otelapi.WithExplicitBucketBoundaries(1, 2, 3, 4, 6, 8, 16, 32, 64),
 	)
 	if err != nil {
 		return err
 	}
-	PeersCount = m
+	ReorgDepth = m
+	return nil
+}
+
+func setupRPCCallDuration(ctx context.Context) error {
+	m, err := meter.Float64Histogram("rpc_call_duration_seconds",
+		otelapi.WithDescription("duration of rpc calls to the builder"),
+		otelapi.WithExplicitBucketBoundaries(.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10),
+	)
+	if err != nil {
+		return err
+	}
+	RPCCallDuration = m
+	return nil
+}
+
+func setupRPCCallsCount(ctx context.Context) error {
+	m, err := meter.Int64Counter("rpc_calls_count",
+		otelapi.WithDescription("count of rpc calls to the builder"),
+	)
+	if err != nil {
+		return err
+	}
+	RPCCallsCount = m
+	return nil
+}
+
+func setupRPCErrorsCount(ctx context.Context) error {
+	m, err := meter.Int64Counter("rpc_errors_count",
+		otelapi.WithDescription("count of failed rpc calls to the builder"),
+	)
+	if err != nil {
+		return err
+	}
+	RPCErrorsCount = m
+	return nil
+}
+
+func setupRPCResponseSize(ctx context.Context) error {
+	m, err := meter.Int64Histogram("rpc_response_size_bytes",
+		otelapi.WithDescription("size of the responses to rpc calls to the builder"),
+		otelapi.WithExplicitBucketBoundaries(256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+	)
+	if err != nil {
+		return err
+	}
+	RPCResponseSize = m
+	return nil
+}
+
+func setupTLSCertificateExpiry(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("tls_certificate_expiry_timestamp",
+		otelapi.WithDescription("unix timestamp of when the tls certificate presented by the builder expires"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TLSCertificateExpiry = m
+	return nil
+}
+
+func setupTxPropagationDelay(ctx context.Context) error {
+	m, err := meter.Float64Histogram("tx_propagation_delay_seconds",
+		otelapi.WithDescription("delay between the tx first appearing in any txpool and in the txpool of the builder"),
+		otelapi.WithExplicitBucketBoundaries(.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600),
+	)
+	if err != nil {
+		return err
+	}
+	TxPropagationDelay = m
+	return nil
+}
+
+func setupTxpoolDivergenceTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_divergence_tx_count",
+		otelapi.WithDescription("count of transactions in the txpool of the other builder that are absent from the txpool of the builder"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolDivergenceTxCount = m
 	return nil
 }
 
@@ -80,11 +373,24 @@ func setupTxpoolDuplicateNonceCount(ctx context.Context) error {
 	return nil
 }
 
+func setupTxpoolIncludedTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_included_tx_count",
+		otelapi.WithDescription("count of transactions missing in the txpool that are already included on chain"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolIncludedTxCount = m
+	return nil
+}
+
 func setupTxpoolNonceGapsLength(ctx context.Context) error {
-	m, err := meter.Int64Gauge("txpool_nonce_gap_length",
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_nonce_gap_length",
 		otelapi.WithDescription("cumulative length of nonce gaps"),
-	)
-	if err != nil {
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
 		return err
 	}
 	TxpoolNonceGapsLength = m
@@ -92,21 +398,131 @@ func setupTxpoolNonceGapsLength(ctx context.Context) error {
 }
 
 func setupTxpoolMissingTxCount(ctx context.Context) error {
-	m, err := meter.Int64Gauge("txpool_missing_tx_count",
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_missing_tx_count",
 		otelapi.WithDescription("count missing transaction in the txpool"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolMissingTxCount = m
+	return nil
+}
+
+func setupTxpoolPendingQueuedMismatchCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_pending_queued_mismatch_count",
+		otelapi.WithDescription("count of transactions queued in the txpool of the builder while pending in the txpool of the other builder"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolPendingQueuedMismatchCount = m
+	return nil
+}
+
+func setupTxpoolPendingTxAge(ctx context.Context) error {
+	m, err := meter.Float64Histogram("txpool_pending_tx_age_seconds",
+		otelapi.WithDescription("for how long the pending transactions have been in the txpool (observed once, when they leave it)"),
+		otelapi.WithExplicitBucketBoundaries(1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600),
 	)
 	if err != nil {
 		return err
 	}
-	TxpoolMissingTxCount = m
+	TxpoolPendingTxAge = m
+	return nil
+}
+
+func setupTxpoolPendingTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_pending_tx_count",
+		otelapi.WithDescription("count of pending transactions in the txpool"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolPendingTxCount = m
+	return nil
+}
+
+func setupTxpoolQueuedTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_queued_tx_count",
+		otelapi.WithDescription("count of queued transactions in the txpool"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolQueuedTxCount = m
+	return nil
+}
+
+func setupTxpoolReplacedTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_replaced_tx_count",
+		otelapi.WithDescription("count of transactions missing in the txpool which nonce is already used by another transaction on chain"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolReplacedTxCount = m
+	return nil
+}
+
+func setupTxpoolSimilarity(ctx context.Context) error {
+	m := newFloat64Gauge()
+	if _, err := meter.Float64ObservableGauge("txpool_similarity",
+		otelapi.WithDescription("jaccard index of the txpools of the builder and the other builder (1 means identical)"),
+		otelapi.WithFloat64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolSimilarity = m
+	return nil
+}
+
+func setupTxpoolSingletonTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_singleton_tx_count",
+		otelapi.WithDescription("count of transactions held in the txpool of the builder only"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolSingletonTxCount = m
+	return nil
+}
+
+func setupTxpoolStaleTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_stale_tx_count",
+		otelapi.WithDescription("count of transactions in the txpool which nonces are already confirmed"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolStaleTxCount = m
+	return nil
+}
+
+func setupTxpoolStuckTxCount(ctx context.Context) error {
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_stuck_tx_count",
+		otelapi.WithDescription("count of transactions that have been pending in the txpool for longer than the threshold"),
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
+		return err
+	}
+	TxpoolStuckTxCount = m
 	return nil
 }
 
 func setupTxpoolUnknownTxCount(ctx context.Context) error {
-	m, err := meter.Int64Gauge("txpool_unknown_tx_count",
+	m := newInt64Gauge()
+	if _, err := meter.Int64ObservableGauge("txpool_unknown_tx_count",
 		otelapi.WithDescription("count of transactions not known to any builder"),
-	)
-	if err != nil {
+		otelapi.WithInt64Callback(m.observe),
+	); err != nil {
 		return err
 	}
 	TxpoolUnknownTxCount = m
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	// retired maps the series that are hidden from the export to the
+	// identity of the attributes they were retired with
+	retired   = make(map[string]string)
+	retiredMx sync.Mutex
+)
+
+// Handler serves the metrics without the retired series (together with the
+// default go and process ones).
+func Handler() http.Handler {
+	return promhttp.HandlerFor(
+		prometheus.Gatherers{prometheus.DefaultGatherer, prometheus.GathererFunc(gather)},
+		promhttp.HandlerOpts{},
+	)
+}
+
+// Retire hides the current series that have every one of the given
+// attributes from the export.  Unlike gauges, counters and histograms can not
+// forget their series, so this is how the series of the removed (or
+// relabelled) builder are dropped.
+func Retire(attributes ...attribute.KeyValue) {
+	families, err := registry.Gather()
+	if err != nil {
+		return
+	}
+
+	identity := attributesIdentity(attributes)
+
+	retiredMx.Lock()
+	defer retiredMx.Unlock()
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if hasLabels(m, attributes) {
+				retired[seriesIdentity(family, m)] = identity
+			}
+		}
+	}
+}
+
+// Revive brings back the series that were retired with exactly the same
+// attributes (e.g. when the removed builder is added back).
+func Revive(attributes ...attribute.KeyValue) {
+	identity := attributesIdentity(attributes)
+
+	retiredMx.Lock()
+	defer retiredMx.Unlock()
+
+	for series, _identity := range retired {
+		if _identity == identity {
+			delete(retired, series)
+		}
+	}
+}
+
+func gather() ([]*dto.MetricFamily, error) {
+	families, err := registry.Gather()
+
+	retiredMx.Lock()
+	defer retiredMx.Unlock()
+
+	if len(retired) == 0 {
+		return families, err
+	}
+
+	res := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		family.Metric = slices.DeleteFunc(family.Metric, func(m *dto.Metric) bool {
+			_, isRetired := retired[seriesIdentity(family, m)]
+			return isRetired
+		})
+		if len(family.Metric) > 0 {
+			res = append(res, family)
+		}
+	}
+
+	return res, err
+}
+
+func hasLabels(m *dto.Metric, attributes []attribute.KeyValue) bool {
+	for _, kv := range attributes {
+		idx := slices.IndexFunc(m.GetLabel(), func(label *dto.LabelPair) bool {
+			return label.GetName() == string(kv.Key)
+		})
+		if idx == -1 || m.GetLabel()[idx].GetValue() != kv.Value.Emit() {
+			return false
+		}
+	}
+	return true
+}
+
+func seriesIdentity(family *dto.MetricFamily, m *dto.Metric) string {
+	var res strings.Builder
+	res.WriteString(family.GetName())
+	for _, label := range m.GetLabel() {
+		res.WriteString("\x00" + label.GetName() + "=" + label.GetValue())
+	}
+	return res.String()
+}
+
+func attributesIdentity(attributes []attribute.KeyValue) string {
+	set := attribute.NewSet(attributes...)
+	return string(set.Encoded(attribute.DefaultEncoder()))
+}
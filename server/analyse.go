@@ -2,26 +2,208 @@ package server
 
 import (
 	"context"
+	"maps"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/flashbots/bmonitor/jrpc"
 	"github.com/flashbots/bmonitor/logutils"
 	"github.com/flashbots/bmonitor/metrics"
 	"github.com/flashbots/bmonitor/types"
-	"github.com/flashbots/bmonitor/utils"
 
+	"github.com/ethereum/go-ethereum/common"
 	"go.opentelemetry.io/otel/attribute"
 	otelapi "go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 )
 
-func (s *Server) analysePeers(ctx context.Context, status map[string]*types.BuilderStatus) {
+func (s *Server) analyseHead(ctx context.Context, status map[string]*types.BuilderStatus) []*types.Finding {
 	l := logutils.LoggerFromContext(ctx)
 
+	var (
+		highest  *jrpc.EthBlock
+		byNumber = make(map[uint64]map[string][]string) // number -> hash -> builders
+	)
+
+	for builder, builderStatus := range status {
+		head := builderStatus.Head
+		if head == nil {
+			attr := attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)}
+			metrics.ChainHeadNumber.Forget(attr)
+			metrics.ChainHeadLagBlocks.Forget(attr)
+			metrics.ChainHeadLagSeconds.Forget(attr)
+			continue
+		}
+
+		if highest == nil || head.Number > highest.Number {
+			highest = head
+		}
+
+		number := uint64(head.Number)
+		if _, known := byNumber[number]; !known {
+			byNumber[number] = make(map[string][]string)
+		}
+		byNumber[number][head.Hash] = append(byNumber[number][head.Hash], builder)
+	}
+
+	if highest == nil {
+		return nil
+	}
+
+	for builder, builderStatus := range status {
+		head := builderStatus.Head
+		if head == nil {
+			continue
+		}
+
+		lagBlocks := int64(highest.Number) - int64(head.Number)
+		lagSeconds := int64(highest.Timestamp) - int64(head.Timestamp)
+
+		if lagBlocks > 0 {
+			l.Debug("Builder's head lags behind",
+				zap.String("builder", builder),
+				zap.Uint64("head_number", uint64(head.Number)),
+				zap.Uint64("highest_number", uint64(highest.Number)),
+				zap.Int64("lag_blocks", lagBlocks),
+				zap.Int64("lag_seconds", lagSeconds),
+			)
+		}
+
+		metrics.ChainHeadNumber.Record(ctx, int64(head.Number), otelapi.WithAttributes(
+			s.builderAttributes(builder)...,
+		))
+
+		metrics.ChainHeadLagBlocks.Record(ctx, lagBlocks, otelapi.WithAttributes(
+			s.builderAttributes(builder)...,
+		))
+
+		metrics.ChainHeadLagSeconds.Record(ctx, lagSeconds, otelapi.WithAttributes(
+			s.builderAttributes(builder)...,
+		))
+	}
+
+	findings := make([]*types.Finding, 0)
+	for number, hashes := range byNumber {
+		if len(hashes) < 2 {
+			continue
+		}
+		blockHashes := slices.Sorted(maps.Keys(hashes))
+		for _, builders := range hashes {
+			for _, builder := range builders {
+				findings = append(findings, &types.Finding{
+					Kind:        types.FindingChainFork,
+					Severity:    types.SeverityWarning,
+					Message:     "Fork detected: builders report different blocks at same height",
+					Builder:     builder,
+					BlockNumber: number,
+					BlockHashes: blockHashes,
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+func (s *Server) analyseReorgs(ctx context.Context, status map[string]*types.BuilderStatus) []*types.Finding {
+	l := logutils.LoggerFromContext(ctx)
+
+	findings := make([]*types.Finding, 0)
+
+	for builder, builderStatus := range status {
+		head := builderStatus.Head
+		if head == nil {
+			continue
+		}
+
+		history := s.heads[builder]
+		if history.filled && history.source != builderStatus.HeadSource {
+			l.Debug("Builder's head source has changed, starting over",
+				zap.String("builder", builder),
+				zap.String("old_source", history.source),
+				zap.String("new_source", builderStatus.HeadSource),
+			)
+			*history = *newHeadHistory(len(history.blocks))
+		}
+		if !history.filled {
+			history.source = builderStatus.HeadSource
+			history.put(uint64(head.Number), head.Hash)
+			continue
+		}
+
+		// heights above the new head are not canonical anymore
+		depth := history.truncate(uint64(head.Number))
+
+		// walk back from the new head until we meet the block we already know
+		blocks := []*jrpc.EthBlock{head}
+		for block := head; ; {
+			if hash, known := history.get(uint64(block.Number)); known {
+				if hash == block.Hash {
+					break
+				}
+				l.Debug("Canonical block at height has changed",
+					zap.String("builder", builder),
+					zap.Uint64("number", uint64(block.Number)),
+					zap.String("old_hash", hash),
+					zap.String("new_hash", block.Hash),
+				)
+				depth++
+			}
+			if !history.filled || block.Number == 0 || uint64(block.Number) <= history.lowest || uint64(len(blocks)) >= history.size() {
+				break
+			}
+			if hash, known := history.get(uint64(block.Number) - 1); known && hash == block.ParentHash {
+				break
+			}
+			parent, err := s.getBlockByHash(ctx, builder, block.ParentHash)
+			if err != nil {
+				l.Warn("Failed to get parent block",
+					zap.Error(err),
+					zap.String("builder", builder),
+					zap.String("hash", block.ParentHash),
+				)
+				break
+			}
+			blocks = append(blocks, parent)
+			block = parent
+		}
+
+		for idx := len(blocks) - 1; idx >= 0; idx-- {
+			history.put(uint64(blocks[idx].Number), blocks[idx].Hash)
+		}
+
+		if depth == 0 {
+			continue
+		}
+
+		findings = append(findings, &types.Finding{
+			Kind:        types.FindingReorg,
+			Severity:    types.SeverityWarning,
+			Message:     "Reorg detected",
+			Builder:     builder,
+			BlockNumber: uint64(head.Number),
+			BlockHashes: []string{head.Hash},
+			Depth:       depth,
+		})
+	}
+
+	return findings
+}
+
+func (s *Server) analysePeers(ctx context.Context, status map[string]*types.BuilderStatus) map[string]*types.PeersReport {
+	l := logutils.LoggerFromContext(ctx)
+
+	reports := make(map[string]*types.PeersReport, len(status))
+
 	for builder, builderStatus := range status {
 		if builderStatus.Peers == nil {
+			metrics.PeersCount.Forget(
+				attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+			)
 			continue
 		}
 
@@ -63,39 +245,47 @@ func (s *Server) analysePeers(ctx context.Context, status map[string]*types.Buil
 			}
 		}
 
-		metrics.PeersCount.Record(ctx, loopback, otelapi.WithAttributes(
-			attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+		metrics.PeersCount.Record(ctx, loopback, otelapi.WithAttributes(s.builderAttributes(builder,
 			attribute.KeyValue{Key: "type", Value: attribute.StringValue("loopback")},
-		))
+		)...))
 
-		metrics.PeersCount.Record(ctx, internal, otelapi.WithAttributes(
-			attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+		metrics.PeersCount.Record(ctx, internal, otelapi.WithAttributes(s.builderAttributes(builder,
 			attribute.KeyValue{Key: "type", Value: attribute.StringValue("internal")},
-		))
+		)...))
 
-		metrics.PeersCount.Record(ctx, external, otelapi.WithAttributes(
-			attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+		metrics.PeersCount.Record(ctx, external, otelapi.WithAttributes(s.builderAttributes(builder,
 			attribute.KeyValue{Key: "type", Value: attribute.StringValue("external")},
-		))
+		)...))
 
 		for label, count := range labelled {
-			metrics.PeersCount.Record(ctx, count, otelapi.WithAttributes(
-				attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+			metrics.PeersCount.Record(ctx, count, otelapi.WithAttributes(s.builderAttributes(builder,
 				attribute.KeyValue{Key: "type", Value: attribute.StringValue("labelled")},
 				attribute.KeyValue{Key: "label", Value: attribute.StringValue(label)},
-			))
+			)...))
+		}
+
+		reports[builder] = &types.PeersReport{
+			Loopback: loopback,
+			Internal: internal,
+			External: external,
+			Labelled: labelled,
 		}
 	}
+
+	return reports
 }
 
-func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.BuilderStatus) {
+func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.BuilderStatus) ([]*types.Finding, map[string]map[string]*types.DivergenceReport) {
 	l := logutils.LoggerFromContext(ctx)
 
-	size := 0
+	findings := make([]*types.Finding, 0)
+
+	size, pools := 0, 0
 	for _, sts := range status {
 		if sts.Txpool == nil {
 			continue
 		}
+		pools++
 		candidate := len(sts.Txpool.Pending) + len(sts.Txpool.Queued)
 		if candidate > size {
 			size = candidate
@@ -104,6 +294,7 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 
 	var (
 		txpoolByHash        = make(map[string]*jrpc.TxpoolContent_Tx, size)
+		txpoolHolders       = make(map[string][]string, size) // tx hash -> builders
 		txpoolByAddrNonce   = make(map[string]map[uint64]*jrpc.TxpoolContent_Tx, size)
 		nonceMin            = make(map[string]uint64)
 		nonceMax            = make(map[string]uint64)
@@ -112,18 +303,25 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 	)
 
 	ingestTx := func(tx *jrpc.TxpoolContent_Tx, builder string) {
-		if _, known := addresses[tx.From]; !known {
-			addresses[tx.From] = struct{}{}
+		// txpool_content lists the accounts by their checksummed addresses,
+		// while the txs carry them in lowercase
+		from := common.HexToAddress(tx.From).Hex()
+
+		if _, known := addresses[from]; !known {
+			addresses[from] = struct{}{}
 		}
 
 		if _, known := txpoolByHash[tx.Hash]; !known {
 			txpoolByHash[tx.Hash] = tx
 		}
+		if holders := txpoolHolders[tx.Hash]; len(holders) == 0 || holders[len(holders)-1] != builder {
+			txpoolHolders[tx.Hash] = append(holders, builder)
+		}
 
-		if _, known := txpoolByAddrNonce[tx.From]; !known {
-			txpoolByAddrNonce[tx.From] = make(map[uint64]*jrpc.TxpoolContent_Tx)
+		if _, known := txpoolByAddrNonce[from]; !known {
+			txpoolByAddrNonce[from] = make(map[uint64]*jrpc.TxpoolContent_Tx)
 		}
-		txpoolByNonce := txpoolByAddrNonce[tx.From]
+		txpoolByNonce := txpoolByAddrNonce[from]
 
 		nonce, err := strconv.ParseUint(strings.TrimPrefix(tx.Nonce, "0x"), 16, 64)
 		if err != nil {
@@ -138,27 +336,27 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 		if knownTx, known := txpoolByNonce[nonce]; !known {
 			txpoolByNonce[nonce] = tx
 		} else if knownTx.Hash != tx.Hash {
-			l.Warn("Multiple tx from same address and nonce",
-				zap.String("from", tx.From),
-				zap.String("known_tx_hash", knownTx.Hash),
-				zap.String("other_tx_hash", tx.Hash),
-				zap.String("builder", builder),
-			)
-			metrics.TxpoolDuplicateNonceCount.Add(ctx, 1, otelapi.WithAttributes(
-				attribute.KeyValue{Key: "from", Value: attribute.StringValue(tx.From)},
-			))
+			findings = append(findings, &types.Finding{
+				Kind:     types.FindingDuplicateNonce,
+				Severity: types.SeverityWarning,
+				Message:  "Multiple tx from same address and nonce",
+				Builder:  builder,
+				Address:  from,
+				Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+				TxHashes: []string{knownTx.Hash, tx.Hash},
+			})
 			return
 		}
 
-		if _, known := nonceMin[tx.From]; !known {
-			nonceMin[tx.From] = nonce
+		if _, known := nonceMin[from]; !known {
+			nonceMin[from] = nonce
 		}
-		nonceMin[tx.From] = min(nonce, nonceMin[tx.From])
+		nonceMin[from] = min(nonce, nonceMin[from])
 
-		if _, known := nonceMax[tx.From]; !known {
-			nonceMax[tx.From] = nonce
+		if _, known := nonceMax[from]; !known {
+			nonceMax[from] = nonce
 		}
-		nonceMax[tx.From] = max(nonce, nonceMax[tx.From])
+		nonceMax[from] = max(nonce, nonceMax[from])
 	}
 
 	{ // warm up the data
@@ -183,6 +381,34 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 		zap.Int("size", len(txpoolByHash)),
 	)
 
+	divergence := s.analyseDivergence(ctx, status, txpoolHolders)
+	findings = append(findings, s.analyseBuckets(ctx, status)...)
+
+	s.analyseSingletons(ctx, status, txpoolHolders)
+	quorum := s.cfg.Monitor.MissingQuorumOf(pools)
+
+	now := time.Now()
+	delays, ages := s.txs.observe(now, status)
+	for builder, delays := range delays {
+		for _, delay := range delays {
+			metrics.TxPropagationDelay.Record(ctx, delay.Seconds(), otelapi.WithAttributes(
+				s.builderAttributes(builder)...,
+			))
+		}
+	}
+	for builder, ages := range ages {
+		for _, age := range ages {
+			metrics.TxpoolPendingTxAge.Record(ctx, age.Seconds(), otelapi.WithAttributes(
+				s.builderAttributes(builder)...,
+			))
+		}
+	}
+
+	canonical, canonicalHead := s.canonical(ctx, status)
+	nonces := s.getConfirmedNonces(ctx, status, canonical, canonicalHead, addresses)
+
+	findings = append(findings, s.analyseStuck(ctx, now, status, nonces)...)
+
 	for builder, sts := range status {
 		if sts.Txpool == nil {
 			continue
@@ -194,30 +420,34 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 			zap.Int("queued", len(sts.Txpool.Queued)),
 		)
 
-		missingTxCount := int64(0)
-		nonceGapsLength := uint64(0)
-
 		for addr := range addresses {
 			pending := sts.Txpool.Pending[addr]
 			queued := sts.Txpool.Queued[addr]
 
-			addrEth, err := utils.ParseAddress(addr)
-			if err != nil {
-				l.Warn("Failed to parse a tx from address",
-					zap.Error(err),
-					zap.String("addr", addr),
-					zap.String("builder", builder),
-				)
-				continue
+			noncePending, known := nonces[canonical][addr]
+			nonceOwn, knownOwn := nonces[builder][addr]
+			if known && knownOwn && nonceOwn != noncePending {
+				findings = append(findings, &types.Finding{
+					Kind:     types.FindingNonceMismatch,
+					Severity: types.SeverityDebug,
+					Message:  "Builder's confirmed nonce disagrees with the canonical one",
+					Builder:  builder,
+					Address:  addr,
+					Nonces: &types.NonceRange{
+						Start: min(nonceOwn, noncePending),
+						End:   max(nonceOwn, noncePending) - 1,
+					},
+				})
 			}
-
-			noncePending, err := s.builders[builder].NonceAt(ctx, addrEth, nil)
-			if err != nil {
-				l.Warn("Failed to get pending nonce",
-					zap.Error(err),
-					zap.String("addr", addr),
-					zap.String("builder", builder),
-				)
+			if knownOwn {
+				if f := findStaleTxs(builder, addr, nonceOwn, pending, queued); f != nil {
+					findings = append(findings, f)
+				}
+			}
+			if !known {
+				noncePending, known = nonceOwn, knownOwn
+			}
+			if !known {
 				continue
 			}
 
@@ -247,36 +477,50 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 				queuedTx, isQueued := queued[strNonce]
 				tx := txpoolByAddrNonce[addr][nonce]
 
+				// the tx that is absent from the snapshot, but was announced
+				// by the builder via subscription is known to it nevertheless
+				isAnnounced := tx != nil && sts.Stream.IsAnnounced(tx.Hash)
+
+				// the tx that was seen elsewhere only recently might still be
+				// on its way to the builder
+				isPropagating := tx != nil && s.txs.age(tx.Hash, now) < s.cfg.Monitor.PropagationGrace
+
+				// the tx that is held by too few builders is not expected to
+				// be present everywhere (e.g. junk accepted by one of them)
+				isBelowQuorum := tx != nil && len(txpoolHolders[tx.Hash]) < quorum
+
 				switch {
 
-				case isPending == !isQueued:
+				case isPending == !isQueued, !isPending && !isQueued && (isAnnounced || isPropagating || isBelowQuorum):
 					if nonceGapStart != 0 {
-						length := nonce - nonceGapStart
-						nonceGapsLength += length
-						l.Warn("Nonce gap detected",
-							zap.String("builder", builder),
-							zap.String("from", addr),
-							zap.Uint64("nonce_gap_start", nonceGapStart),
-							zap.Uint64("nonce_gap_end", nonce-1),
-							zap.Uint64("nonce_gap_length", length),
-						)
+						findings = append(findings, &types.Finding{
+							Kind:     types.FindingNonceGap,
+							Severity: types.SeverityWarning,
+							Message:  "Nonce gap detected",
+							Builder:  builder,
+							Address:  addr,
+							Nonces:   &types.NonceRange{Start: nonceGapStart, End: nonce - 1},
+						})
 						nonceGapStart = 0
 					}
 					continue
 
 				case isPending && isQueued:
-					l.Warn("Same tx is both pending and queued (should never be the case)",
-						zap.String("builder", builder),
-						zap.String("pending_tx_hash", pendingTx.Hash),
-						zap.String("queued_tx_hash", queuedTx.Hash),
-					)
+					findings = append(findings, &types.Finding{
+						Kind:     types.FindingPendingAndQueued,
+						Severity: types.SeverityWarning,
+						Message:  "Same tx is both pending and queued (should never be the case)",
+						Builder:  builder,
+						Address:  addr,
+						Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+						TxHashes: []string{pendingTx.Hash, queuedTx.Hash},
+					})
 					continue
 
 				default:
 					if nonceGapStart == 0 {
 						nonceGapStart = nonce
 					}
-					missingTxCount++
 
 					if tx == nil {
 						if _, exists := unknownTransactions[addr]; !exists {
@@ -285,38 +529,148 @@ func (s *Server) analyseTxpool(ctx context.Context, status map[string]*types.Bui
 						if _, exists := unknownTransactions[addr][nonce]; !exists {
 							unknownTransactions[addr][nonce] = struct{}{}
 						}
+						findings = append(findings, &types.Finding{
+							Kind:     types.FindingMissingTx,
+							Severity: types.SeverityDebug,
+							Message:  "Tx is not known to the builder (nor to any other one)",
+							Builder:  builder,
+							Address:  addr,
+							Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+						})
 						continue
 					}
 
-					l.Warn("Tx is not known to the builder",
-						zap.String("builder", builder),
-						zap.String("from", addr),
-						zap.String("nonce", strNonce),
-						zap.String("tx_hash", tx.Hash),
-					)
+					findings = append(findings, &types.Finding{
+						Kind:     types.FindingMissingTx,
+						Severity: types.SeverityWarning,
+						Message:  "Tx is not known to the builder",
+						Builder:  builder,
+						Address:  addr,
+						Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+						TxHashes: []string{tx.Hash},
+					})
 				}
 			}
 		}
+	}
 
-		metrics.TxpoolNonceGapsLength.Record(ctx, int64(nonceGapsLength), otelapi.WithAttributes(
-			attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
-		))
+	for addr, nonces := range unknownTransactions {
+		for nonce := range nonces {
+			findings = append(findings, &types.Finding{
+				Kind:     types.FindingUnknownTx,
+				Severity: types.SeverityWarning,
+				Message:  "Tx is not known to any builder",
+				Address:  addr,
+				Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+			})
+		}
+	}
 
-		metrics.TxpoolMissingTxCount.Record(ctx, missingTxCount, otelapi.WithAttributes(
-			attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+	return s.confirmMissing(ctx, canonical, findings), divergence
+}
+
+// analyseSingletons records the counts of the txs that are held by one
+// builder only.
+func (s *Server) analyseSingletons(ctx context.Context, status map[string]*types.BuilderStatus, txpoolHolders map[string][]string) {
+	singletons := make(map[string]int64)
+	for _, holders := range txpoolHolders {
+		if len(holders) == 1 {
+			singletons[holders[0]]++
+		}
+	}
+
+	for builder, sts := range status {
+		if sts.Txpool == nil {
+			metrics.TxpoolSingletonTxCount.Forget(
+				attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)},
+			)
+			continue
+		}
+		metrics.TxpoolSingletonTxCount.Record(ctx, singletons[builder], otelapi.WithAttributes(
+			s.builderAttributes(builder)...,
 		))
 	}
+}
 
-	unknownTransactionsCount := 0
-	for addr, nonces := range unknownTransactions {
-		unknownTransactionsCount += len(nonces)
-		for nonce := range nonces {
-			l.Warn("Tx is not known to any builder",
-				zap.String("from", addr),
-				zap.Uint64("nonce", nonce),
+// canonical returns the name and the head of the node that is the source of
+// confirmed nonces that we rely upon: either the reference node, or the
+// builder with the highest head (the ties are broken by the name).  The name
+// is empty if there is neither.
+func (s *Server) canonical(ctx context.Context, status map[string]*types.BuilderStatus) (string, *jrpc.EthBlock) {
+	if s.reference != nil {
+		head, _, err := s.getHead(ctx, s.referenceName)
+		if err != nil {
+			logutils.LoggerFromContext(ctx).Warn("Failed to get reference node's head",
+				zap.Error(err),
+				zap.String("reference", s.referenceName),
 			)
 		}
+		return s.referenceName, head
+	}
+
+	var (
+		canonical string
+		head      *jrpc.EthBlock
+	)
+	for builder, sts := range status {
+		if sts.Head == nil {
+			continue
+		}
+		if head == nil ||
+			sts.Head.Number > head.Number ||
+			(sts.Head.Number == head.Number && builder < canonical) {
+			canonical, head = builder, sts.Head
+		}
 	}
 
-	metrics.TxpoolUnknownTxCount.Record(ctx, int64(unknownTransactionsCount))
+	return canonical, head
+}
+
+// getConfirmedNonces fetches the confirmed nonces of the addresses (node ->
+// address -> nonce) from the builders which txpools were observed, as of
+// their heads, and from the canonical node.
+func (s *Server) getConfirmedNonces(
+	ctx context.Context,
+	status map[string]*types.BuilderStatus,
+	canonical string,
+	canonicalHead *jrpc.EthBlock,
+	addresses map[string]struct{},
+) map[string]map[string]uint64 {
+	list := make([]string, 0, len(addresses))
+	for addr := range addresses {
+		list = append(list, addr)
+	}
+
+	heads := make(map[string]*jrpc.EthBlock, len(status)+1)
+	for builder, sts := range status {
+		if sts.Txpool != nil {
+			heads[builder] = sts.Head
+		}
+	}
+	if canonical != "" {
+		heads[canonical] = canonicalHead
+	}
+
+	var (
+		mx     sync.Mutex
+		wg     sync.WaitGroup
+		nonces = make(map[string]map[string]uint64, len(heads))
+	)
+
+	for name, head := range heads {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res := s.getNonces(ctx, name, head, list)
+			mx.Lock()
+			nonces[name] = res
+			mx.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return nonces
 }
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// testChain serves the blocks by their hashes via json-rpc.
+type testChain struct {
+	blocks map[string]*jrpc.EthBlock
+}
+
+func (c *testChain) GetBlockByHash(hash string, _ bool) (*jrpc.EthBlock, error) {
+	return c.blocks[hash], nil
+}
+
+func block(number uint64, hash, parentHash string) *jrpc.EthBlock {
+	return &jrpc.EthBlock{
+		Number:     hexutil.Uint64(number),
+		Hash:       hash,
+		ParentHash: parentHash,
+	}
+}
+
+func TestAnalyseReorgs(t *testing.T) {
+	const source = "http://builder"
+
+	for _, tc := range []struct {
+		name      string
+		known     []*jrpc.EthBlock // blocks in the head history
+		chain     []*jrpc.EthBlock // blocks available via rpc
+		head      *jrpc.EthBlock
+		source    string
+		wantDepth int // 0 means no reorg
+		wantKnown []*jrpc.EthBlock
+	}{
+		{
+			name:      "first head is recorded",
+			head:      block(10, "a10", "a9"),
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", "")},
+		},
+		{
+			name:      "same head",
+			known:     []*jrpc.EthBlock{block(10, "a10", "")},
+			head:      block(10, "a10", "a9"),
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", "")},
+		},
+		{
+			name:      "chain extended",
+			known:     []*jrpc.EthBlock{block(10, "a10", "")},
+			head:      block(11, "a11", "a10"),
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", "")},
+		},
+		{
+			name:  "skipped blocks are walked back",
+			known: []*jrpc.EthBlock{block(10, "a10", "")},
+			chain: []*jrpc.EthBlock{block(11, "a11", "a10"), block(12, "a12", "a11")},
+			head:  block(13, "a13", "a12"),
+			wantKnown: []*jrpc.EthBlock{
+				block(10, "a10", ""), block(11, "a11", ""), block(12, "a12", ""), block(13, "a13", ""),
+			},
+		},
+		{
+			name:      "head replaced at same height",
+			known:     []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", "")},
+			head:      block(11, "b11", "a10"),
+			wantDepth: 1,
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", ""), block(11, "b11", "")},
+		},
+		{
+			name:      "reorg found by walking back",
+			known:     []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", ""), block(12, "a12", "")},
+			chain:     []*jrpc.EthBlock{block(11, "b11", "a10"), block(12, "b12", "b11")},
+			head:      block(13, "b13", "b12"),
+			wantDepth: 2,
+			wantKnown: []*jrpc.EthBlock{
+				block(10, "a10", ""), block(11, "b11", ""), block(12, "b12", ""), block(13, "b13", ""),
+			},
+		},
+		{
+			name:      "head rolled back to lower height",
+			known:     []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", ""), block(12, "a12", "")},
+			head:      block(11, "b11", "a10"),
+			wantDepth: 2,
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", ""), block(11, "b11", "")},
+		},
+		{
+			name:      "walk back stops when parent is unavailable",
+			known:     []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", "")},
+			head:      block(13, "b13", "b12"),
+			wantKnown: []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", ""), block(13, "b13", "")},
+		},
+		{
+			name:      "change of head source starts over",
+			known:     []*jrpc.EthBlock{block(10, "a10", ""), block(11, "a11", "")},
+			head:      block(11, "b11", "b10"),
+			source:    "http://other",
+			wantKnown: []*jrpc.EthBlock{block(11, "b11", "")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := &testChain{blocks: make(map[string]*jrpc.EthBlock)}
+			for _, b := range tc.chain {
+				chain.blocks[b.Hash] = b
+			}
+			s := newTestServer(t, "b0")
+			serveTestBuilder(t, s, &config.Builder{Name: "b0"}, map[string]any{"eth": chain})
+
+			history := s.heads["b0"]
+			history.source = source
+			for _, b := range tc.known {
+				history.put(uint64(b.Number), b.Hash)
+			}
+
+			headSource := source
+			if tc.source != "" {
+				headSource = tc.source
+			}
+
+			findings := s.analyseReorgs(context.Background(), map[string]*types.BuilderStatus{
+				"b0": {Head: tc.head, HeadSource: headSource},
+			})
+
+			switch {
+			case tc.wantDepth == 0 && len(findings) != 0:
+				t.Errorf("got %d findings (depth %d), want none", len(findings), findings[0].Depth)
+			case tc.wantDepth != 0 && len(findings) != 1:
+				t.Errorf("got %d findings, want 1", len(findings))
+			case tc.wantDepth != 0 && findings[0].Depth != tc.wantDepth:
+				t.Errorf("got reorg depth %d, want %d", findings[0].Depth, tc.wantDepth)
+			}
+
+			for _, b := range tc.wantKnown {
+				if hash, known := history.get(uint64(b.Number)); !known || hash != b.Hash {
+					t.Errorf("history at %d = %q, %t, want %q", b.Number, hash, known, b.Hash)
+				}
+			}
+			for n := history.lowest; n <= history.highest; n++ {
+				hash, known := history.get(n)
+				if !known {
+					continue
+				}
+				expected := false
+				for _, b := range tc.wantKnown {
+					expected = expected || (uint64(b.Number) == n && b.Hash == hash)
+				}
+				if !expected {
+					t.Errorf("history at %d = %q, want unknown", n, hash)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyseTxpoolStale(t *testing.T) {
+	const (
+		checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		lowercase   = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	)
+
+	canonical := &testCanonical{
+		nonces: map[common.Address]uint64{common.HexToAddress(checksummed): 5},
+	}
+
+	s := newTestServer(t, "b0")
+	serveTestBuilder(t, s, &config.Builder{Name: "b0"}, map[string]any{"eth": canonical})
+
+	findings, _ := s.analyseTxpool(context.Background(), map[string]*types.BuilderStatus{
+		"b0": {
+			Head: block(10, "a10", "a9"),
+			Txpool: &jrpc.TxpoolContent{
+				Pending: map[string]map[string]*jrpc.TxpoolContent_Tx{
+					checksummed: {
+						"3": {From: lowercase, Nonce: "0x3", Hash: "0x03"},
+						"5": {From: lowercase, Nonce: "0x5", Hash: "0x05"},
+					},
+				},
+			},
+		},
+	})
+
+	stale := make([]*types.Finding, 0)
+	for _, f := range findings {
+		if f.Kind == types.FindingStaleTx {
+			stale = append(stale, f)
+		}
+	}
+	if len(stale) != 1 {
+		t.Fatalf("got %d stale tx findings, want 1 (findings: %v)", len(stale), findings)
+	}
+	if stale[0].Address != checksummed || stale[0].Nonces.Start != 3 || stale[0].Nonces.End != 3 {
+		t.Errorf("stale txs of %s at %+v, want of %s at 3", stale[0].Address, *stale[0].Nonces, checksummed)
+	}
+}
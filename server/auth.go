@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	errAuthInvalidBearerToken = errors.New("invalid bearer token")
+	errAuthInvalidJWTSecret   = errors.New("invalid jwt secret (must be 32 hex-encoded bytes)")
+)
+
+// httpAuth returns the function that authenticates rpc requests to the
+// builder (or nil if the builder does not need one).  The errors it returns
+// never include the secrets.
+func httpAuth(cfg *config.Builder) (rpc.HTTPAuth, error) {
+	switch {
+	case cfg.BearerTokenFile != "":
+		// fail early if the token is not readable, and then re-read it on
+		// every request so that it can be rotated without reload
+		if _, err := readBearerToken(cfg.BearerTokenFile); err != nil {
+			return nil, err
+		}
+		return func(h http.Header) error {
+			token, err := readBearerToken(cfg.BearerTokenFile)
+			if err != nil {
+				return err
+			}
+			h.Set("Authorization", "Bearer "+token)
+			return nil
+		}, nil
+
+	case cfg.JWTSecretFile != "":
+		secret, err := readJWTSecret(cfg.JWTSecretFile)
+		if err != nil {
+			return nil, err
+		}
+		// geth's authrpc expects fresh token (with recent `iat` claim) on
+		// every request
+		return func(h http.Header) error {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"iat": &jwt.NumericDate{Time: time.Now()},
+			}).SignedString(secret)
+			if err != nil {
+				return fmt.Errorf("failed to create jwt token: %w", err)
+			}
+			h.Set("Authorization", "Bearer "+token)
+			return nil
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func readBearerToken(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w",
+			errAuthInvalidBearerToken, err,
+		)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("%w: %s: empty file",
+			errAuthInvalidBearerToken, path,
+		)
+	}
+	return token, nil
+}
+
+func readJWTSecret(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w",
+			errAuthInvalidJWTSecret, err,
+		)
+	}
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(b)), "0x"))
+	if err != nil || len(secret) != 32 {
+		return nil, fmt.Errorf("%w: %s",
+			errAuthInvalidJWTSecret, path,
+		)
+	}
+	return secret, nil
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/jrpc"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testJWTSecret = "0x7365637265747365637265747365637265747365637265747365637265743332"
+
+func TestHTTPAuth(t *testing.T) {
+	type want struct {
+		bearer string // static bearer token
+		jwt    bool   // fresh jwt token signed with the test secret
+	}
+
+	for _, tc := range []struct {
+		name    string
+		cfg     func(dir string) *config.Builder
+		files   map[string]string // name -> content
+		rotate  map[string]string // name -> content after the first call
+		want    []want            // per call
+		wantErr error
+	}{
+		{
+			name: "no auth",
+			cfg:  func(string) *config.Builder { return &config.Builder{} },
+			want: []want{{}},
+		},
+		{
+			name: "authorization header",
+			cfg: func(string) *config.Builder {
+				return &config.Builder{Headers: map[string]string{"Authorization": "Bearer static"}}
+			},
+			want: []want{{bearer: "static"}},
+		},
+		{
+			name:   "bearer token is re-read on every request",
+			cfg:    func(dir string) *config.Builder { return &config.Builder{BearerTokenFile: filepath.Join(dir, "token")} },
+			files:  map[string]string{"token": " first\n"},
+			rotate: map[string]string{"token": "second"},
+			want:   []want{{bearer: "first"}, {bearer: "second"}},
+		},
+		{
+			name:    "empty bearer token",
+			cfg:     func(dir string) *config.Builder { return &config.Builder{BearerTokenFile: filepath.Join(dir, "token")} },
+			files:   map[string]string{"token": "\n"},
+			wantErr: errAuthInvalidBearerToken,
+		},
+		{
+			name:    "missing bearer token",
+			cfg:     func(dir string) *config.Builder { return &config.Builder{BearerTokenFile: filepath.Join(dir, "token")} },
+			wantErr: errAuthInvalidBearerToken,
+		},
+		{
+			name:  "jwt token is issued for every request",
+			cfg:   func(dir string) *config.Builder { return &config.Builder{JWTSecretFile: filepath.Join(dir, "jwt.hex")} },
+			files: map[string]string{"jwt.hex": testJWTSecret + "\n"},
+			want:  []want{{jwt: true}, {jwt: true}},
+		},
+		{
+			name:    "short jwt secret",
+			cfg:     func(dir string) *config.Builder { return &config.Builder{JWTSecretFile: filepath.Join(dir, "jwt.hex")} },
+			files:   map[string]string{"jwt.hex": "0x736563726574"},
+			wantErr: errAuthInvalidJWTSecret,
+		},
+		{
+			name:    "malformed jwt secret",
+			cfg:     func(dir string) *config.Builder { return &config.Builder{JWTSecretFile: filepath.Join(dir, "jwt.hex")} },
+			files:   map[string]string{"jwt.hex": strings.Repeat("zz", 32)},
+			wantErr: errAuthInvalidJWTSecret,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mx      sync.Mutex
+				headers []string
+			)
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", testHead{}); err != nil {
+				t.Fatal(err)
+			}
+			httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mx.Lock()
+				headers = append(headers, r.Header.Get("Authorization"))
+				mx.Unlock()
+				srv.ServeHTTP(w, r)
+			}))
+			defer httpSrv.Close()
+
+			dir := t.TempDir()
+			write := func(files map[string]string) {
+				for name, content := range files {
+					if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			write(tc.files)
+
+			cfg := tc.cfg(dir)
+			cfg.Name, cfg.URL = "b0", httpSrv.URL
+
+			b, err := dialBuilder(context.Background(), cfg, time.Second)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("dialBuilder() error = %v, want %v", err, tc.wantErr)
+				}
+				for _, content := range tc.files {
+					if secret := strings.TrimSpace(content); secret != "" && strings.Contains(err.Error(), secret) {
+						t.Errorf("dialBuilder() error = %v, want no secrets in it", err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.close()
+
+			for idx, want := range tc.want {
+				if idx == 1 {
+					write(tc.rotate)
+				}
+
+				called := time.Now()
+				var res *jrpc.EthBlock
+				if err := b.endpoints[0].client.CallContext(context.Background(), &res, "eth_getBlockByNumber", "latest", false); err != nil {
+					t.Fatal(err)
+				}
+
+				mx.Lock()
+				header := headers[len(headers)-1]
+				mx.Unlock()
+
+				switch {
+				case want.jwt:
+					token, err := jwt.Parse(strings.TrimPrefix(header, "Bearer "), func(token *jwt.Token) (any, error) {
+						if token.Method != jwt.SigningMethodHS256 {
+							return nil, errors.New("unexpected signing method")
+						}
+						return hexutil.MustDecode(testJWTSecret), nil
+					})
+					if err != nil {
+						t.Fatalf("call %d: invalid jwt token %q: %v", idx, header, err)
+					}
+					iat, ok := token.Claims.(jwt.MapClaims)["iat"].(float64)
+					if !ok {
+						t.Fatalf("call %d: jwt token without iat claim: %v", idx, token.Claims)
+					}
+					if issued := time.Unix(int64(iat), 0); issued.Before(called.Add(-time.Second)) || issued.After(time.Now()) {
+						t.Errorf("call %d: jwt token issued at %v, want at %v", idx, issued, called)
+					}
+				case want.bearer != "":
+					if header != "Bearer "+want.bearer {
+						t.Errorf("call %d: authorization = %q, want %q", idx, header, "Bearer "+want.bearer)
+					}
+				default:
+					if header != "" {
+						t.Errorf("call %d: authorization = %q, want none", idx, header)
+					}
+				}
+			}
+		})
+	}
+}
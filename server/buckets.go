@@ -0,0 +1,112 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+)
+
+// analyseBuckets cross-compares the txpool buckets (pending or queued) of
+// each (from, nonce) across the builders, and reports the txs that are queued
+// on the builder while being pending on the other one (meaning that the two
+// disagree about the account's state).
+func (s *Server) analyseBuckets(ctx context.Context, status map[string]*types.BuilderStatus) []*types.Finding {
+	type mismatch struct {
+		nonce uint64
+		hash  string
+	}
+
+	var (
+		pending    = make(map[string]map[string][]string)                           // from -> nonce -> builders that have the tx pending
+		mismatches = make(map[string]map[string]map[string][]mismatch, len(status)) // builder -> other -> from -> mismatch
+	)
+
+	for builder, sts := range status {
+		if sts.Txpool == nil {
+			attr := attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)}
+			other := attribute.KeyValue{Key: "other", Value: attribute.StringValue(builder)}
+			metrics.TxpoolPendingQueuedMismatchCount.Forget(attr)
+			metrics.TxpoolPendingQueuedMismatchCount.Forget(other)
+			continue
+		}
+		mismatches[builder] = make(map[string]map[string][]mismatch)
+		for from, nonces := range sts.Txpool.Pending {
+			if _, known := pending[from]; !known {
+				pending[from] = make(map[string][]string)
+			}
+			for nonce := range nonces {
+				pending[from][nonce] = append(pending[from][nonce], builder)
+			}
+		}
+	}
+
+	for builder := range mismatches {
+		for from, nonces := range status[builder].Txpool.Queued {
+			for strNonce, tx := range nonces {
+				nonce, err := strconv.ParseUint(strNonce, 10, 64)
+				if err != nil {
+					continue
+				}
+				for _, other := range pending[from][strNonce] {
+					if other == builder {
+						continue // reported as pending_and_queued
+					}
+					if _, known := mismatches[builder][other]; !known {
+						mismatches[builder][other] = make(map[string][]mismatch)
+					}
+					mismatches[builder][other][from] = append(mismatches[builder][other][from], mismatch{
+						nonce: nonce,
+						hash:  tx.Hash,
+					})
+				}
+			}
+		}
+	}
+
+	findings := make([]*types.Finding, 0)
+	for builder, others := range mismatches {
+		for other := range mismatches {
+			if other == builder {
+				continue
+			}
+
+			count := 0
+			for _, from := range slices.Sorted(maps.Keys(others[other])) {
+				txs := others[other][from]
+				slices.SortFunc(txs, func(a, b mismatch) int {
+					return cmp.Compare(a.nonce, b.nonce)
+				})
+				hashes := make([]string, 0, len(txs))
+				for _, tx := range txs {
+					hashes = append(hashes, tx.hash)
+				}
+				count += len(txs)
+
+				findings = append(findings, &types.Finding{
+					Kind:     types.FindingPendingQueuedMismatch,
+					Severity: types.SeverityWarning,
+					Message:  "Tx is queued on the builder, but is pending on the other one",
+					Builder:  builder,
+					Other:    other,
+					Address:  from,
+					Nonces:   &types.NonceRange{Start: txs[0].nonce, End: txs[len(txs)-1].nonce},
+					TxHashes: hashes,
+				})
+			}
+
+			metrics.TxpoolPendingQueuedMismatchCount.Record(ctx, int64(count), otelapi.WithAttributes(s.builderAttributes(builder,
+				attribute.KeyValue{Key: "other", Value: attribute.StringValue(other)},
+			)...))
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+func TestAnalyseBuckets(t *testing.T) {
+	type txpool struct {
+		pending, queued []string // "from/nonce"
+	}
+
+	type mismatch struct {
+		builder, other, from string
+		start, end           uint64
+		hashes               []string
+	}
+
+	for _, tc := range []struct {
+		name    string
+		txpools map[string]*txpool // nil means no txpool
+		want    []mismatch
+	}{
+		{
+			name: "same buckets everywhere",
+			txpools: map[string]*txpool{
+				"a": {pending: []string{"0x1/1"}, queued: []string{"0x1/5"}},
+				"b": {pending: []string{"0x1/1"}, queued: []string{"0x1/5"}},
+			},
+		},
+		{
+			name: "queued on one, pending on the other",
+			txpools: map[string]*txpool{
+				"a": {queued: []string{"0x1/2", "0x1/1"}},
+				"b": {pending: []string{"0x1/1", "0x1/2"}},
+			},
+			want: []mismatch{
+				{builder: "a", other: "b", from: "0x1", start: 1, end: 2, hashes: []string{"a/0x1/1", "a/0x1/2"}},
+			},
+		},
+		{
+			name: "pending and queued on the same builder is not a mismatch",
+			txpools: map[string]*txpool{
+				"a": {pending: []string{"0x1/1"}, queued: []string{"0x1/1"}},
+			},
+		},
+		{
+			name: "mismatches are reported per address and per other builder",
+			txpools: map[string]*txpool{
+				"a": {queued: []string{"0x1/1", "0x2/7"}},
+				"b": {pending: []string{"0x1/1"}},
+				"c": {pending: []string{"0x1/1", "0x2/7"}},
+			},
+			want: []mismatch{
+				{builder: "a", other: "b", from: "0x1", start: 1, end: 1, hashes: []string{"a/0x1/1"}},
+				{builder: "a", other: "c", from: "0x1", start: 1, end: 1, hashes: []string{"a/0x1/1"}},
+				{builder: "a", other: "c", from: "0x2", start: 7, end: 7, hashes: []string{"a/0x2/7"}},
+			},
+		},
+		{
+			name: "mismatches in both directions",
+			txpools: map[string]*txpool{
+				"a": {pending: []string{"0x1/1"}, queued: []string{"0x2/3"}},
+				"b": {pending: []string{"0x2/3"}, queued: []string{"0x1/1"}},
+			},
+			want: []mismatch{
+				{builder: "a", other: "b", from: "0x2", start: 3, end: 3, hashes: []string{"a/0x2/3"}},
+				{builder: "b", other: "a", from: "0x1", start: 1, end: 1, hashes: []string{"b/0x1/1"}},
+			},
+		},
+		{
+			name: "builders without txpool are skipped",
+			txpools: map[string]*txpool{
+				"a": {queued: []string{"0x1/1"}},
+				"b": nil,
+			},
+		},
+		{
+			name: "malformed nonces are ignored",
+			txpools: map[string]*txpool{
+				"a": {queued: []string{"0x1/0x1"}},
+				"b": {pending: []string{"0x1/0x1"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, slices.Collect(maps.Keys(tc.txpools))...)
+
+			bucket := func(builder string, txs []string) map[string]map[string]*jrpc.TxpoolContent_Tx {
+				res := make(map[string]map[string]*jrpc.TxpoolContent_Tx)
+				for _, tx := range txs {
+					from, nonce, _ := strings.Cut(tx, "/")
+					if _, known := res[from]; !known {
+						res[from] = make(map[string]*jrpc.TxpoolContent_Tx)
+					}
+					res[from][nonce] = &jrpc.TxpoolContent_Tx{From: from, Nonce: nonce, Hash: builder + "/" + tx}
+				}
+				return res
+			}
+
+			status := make(map[string]*types.BuilderStatus, len(tc.txpools))
+			for builder, pool := range tc.txpools {
+				if pool == nil {
+					status[builder] = &types.BuilderStatus{}
+					continue
+				}
+				status[builder] = &types.BuilderStatus{Txpool: &jrpc.TxpoolContent{
+					Pending: bucket(builder, pool.pending),
+					Queued:  bucket(builder, pool.queued),
+				}}
+			}
+
+			got := make([]mismatch, 0)
+			for _, f := range s.analyseBuckets(context.Background(), status) {
+				if f.Kind != types.FindingPendingQueuedMismatch {
+					t.Errorf("unexpected finding kind: %s", f.Kind)
+				}
+				got = append(got, mismatch{
+					builder: f.Builder,
+					other:   f.Other,
+					from:    f.Address,
+					start:   f.Nonces.Start,
+					end:     f.Nonces.End,
+					hashes:  f.TxHashes,
+				})
+			}
+			slices.SortFunc(got, func(a, b mismatch) int {
+				return cmp.Or(
+					cmp.Compare(a.builder, b.builder),
+					cmp.Compare(a.other, b.other),
+					cmp.Compare(a.from, b.from),
+				)
+			})
+
+			want := tc.want
+			if want == nil {
+				want = []mismatch{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("analyseBuckets() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// builder is a monitored node (or the reference one) together with the rpc
+// clients of its endpoints.
+type builder struct {
+	cfg       *config.Builder
+	endpoints []*endpoint
+	stream    *stream // nil unless websocket subscriptions are configured
+
+	active atomic.Int64 // index of the endpoint that served the last successful call
+}
+
+// endpoint is one of the urls at which the builder is reachable.
+type endpoint struct {
+	client *rpc.Client
+	label  string // url without credentials, path, and query, followed by the index of the endpoint (safe for metrics and logs)
+
+	certExpiry atomic.Int64 // unix timestamp of when endpoint's tls certificate expires
+	failedAt   atomic.Int64 // unix nano timestamp of the last failure (0 if healthy)
+}
+
+// dialBuilder connects to the endpoints of the builder, giving up after the
+// builder's rpc timeout (or the given default one).
+func dialBuilder(ctx context.Context, cfg *config.Builder, timeout time.Duration) (*builder, error) {
+	b := &builder{cfg: cfg}
+
+	if cfg.Timeout != 0 {
+		timeout = cfg.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	headers := make(http.Header, len(cfg.Headers))
+	for header, value := range cfg.Headers {
+		headers.Set(header, value)
+	}
+
+	auth, err := httpAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, rawurl := range cfg.Endpoints() {
+		e, err := dialEndpoint(ctx, cfg, rawurl, endpointLabel(rawurl, idx), headers, auth)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		b.endpoints = append(b.endpoints, e)
+	}
+
+	if cfg.WSURL != "" {
+		b.stream = newStream(redactURL(cfg.WSURL), func(ctx context.Context) (*endpoint, error) {
+			return dialEndpoint(ctx, cfg, cfg.WSURL, redactURL(cfg.WSURL), headers, auth)
+		})
+	}
+
+	return b, nil
+}
+
+func dialEndpoint(ctx context.Context, cfg *config.Builder, rawurl, label string, headers http.Header, auth rpc.HTTPAuth) (*endpoint, error) {
+	e := &endpoint{label: label}
+
+	tlsCfg, err := tlsConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg.VerifyConnection = e.verifyConnection
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	options := []rpc.ClientOption{
+		rpc.WithHeaders(headers),
+		rpc.WithHTTPClient(&http.Client{Transport: transport}),
+		rpc.WithWebsocketDialer(websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 45 * time.Second,
+			ReadBufferSize:   1024,
+			WriteBufferSize:  1024,
+			TLSClientConfig:  tlsCfg,
+		}),
+	}
+	if auth != nil {
+		options = append(options, rpc.WithHTTPAuth(auth))
+	}
+
+	client, err := rpc.DialOptions(ctx, rawurl, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.label, redactError(err))
+	}
+	e.client = client
+
+	return e, nil
+}
+
+// start starts websocket subscriptions of the builder (if configured).
+func (b *builder) start(l *zap.Logger) {
+	if b.stream != nil {
+		b.stream.start(l.With(
+			zap.String("builder", b.cfg.Name),
+		))
+	}
+}
+
+func (b *builder) close() {
+	if b.stream != nil {
+		b.stream.stop()
+	}
+	for _, e := range b.endpoints {
+		e.client.Close()
+	}
+}
+
+// order returns the indices of the endpoints in the order in which they
+// should be tried: healthy ones first (in the configured order), followed
+// by the ones that have failed recently.
+func (b *builder) order() []int {
+	var (
+		healthy   = make([]int, 0, len(b.endpoints))
+		unhealthy = make([]int, 0)
+		cutoff    = time.Now().Add(-endpointCooldown).UnixNano()
+	)
+	for idx, e := range b.endpoints {
+		if failedAt := e.failedAt.Load(); failedAt == 0 || failedAt < cutoff {
+			healthy = append(healthy, idx)
+		} else {
+			unhealthy = append(unhealthy, idx)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// verifyConnection keeps track of the expiry of endpoint's tls certificate.
+func (e *endpoint) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) > 0 {
+		e.certExpiry.Store(state.PeerCertificates[0].NotAfter.Unix())
+	}
+	return nil
+}
+
+// endpointLabel returns the label of the builder's endpoint: its redacted url
+// followed by its index (as several endpoints might share the same host, e.g.
+// the ones of the same provider with different api keys).
+func endpointLabel(rawurl string, idx int) string {
+	return redactURL(rawurl) + "#" + strconv.Itoa(idx)
+}
+
+// redactURL strips everything but the scheme and the host from the url, as
+// the rest of it might carry the secrets (e.g. api keys).  The ipc endpoints
+// are returned as-is.
+func redactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	switch {
+	case err != nil:
+		return "redacted"
+	case u.Scheme == "":
+		return rawurl
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// redactError strips the secrets from the url reported by the http client
+// error (which keeps everything but the password of the user).  The error is
+// modified in place, so that the chain of the errors stays intact.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+	return err
+}
+
+// builder returns the builder (or the reference node) by its name.
+func (s *Server) builder(name string) *builder {
+	if s.reference != nil && name == s.referenceName {
+		return s.reference
+	}
+	return s.builders[name]
+}
+
+// timeout returns the rpc timeout of the builder.
+func (s *Server) timeout(name string) time.Duration {
+	if b := s.builder(name); b != nil && b.cfg.Timeout != 0 {
+		return b.cfg.Timeout
+	}
+	return s.cfg.Monitor.Timeout
+}
+
+// isEnabled returns true if the check is enabled for the builder.
+func (s *Server) isEnabled(name, check string) bool {
+	if b := s.builder(name); b != nil {
+		return b.cfg.IsEnabled(check)
+	}
+	return true
+}
+
+// labels returns custom labels of the builder.
+func (s *Server) labels(name string) map[string]string {
+	if b := s.builder(name); b != nil {
+		return b.cfg.Labels
+	}
+	return nil
+}
+
+// builderAttributes returns metric attributes that identify the builder: its
+// name followed by its custom labels.  The labels that are not set for the
+// builder are reported empty, so that all series of the metric share the same
+// set of attributes.
+func (s *Server) builderAttributes(name string, extra ...attribute.KeyValue) []attribute.KeyValue {
+	labels := s.labels(name)
+
+	res := make([]attribute.KeyValue, 0, 1+len(s.labelKeys)+len(extra))
+	res = append(res, attribute.KeyValue{Key: "builder", Value: attribute.StringValue(name)})
+	for _, key := range s.labelKeys {
+		res = append(res, attribute.KeyValue{Key: attribute.Key(key), Value: attribute.StringValue(labels[key])})
+	}
+
+	return append(res, extra...)
+}
+
+// endpointAttribute returns metric attribute that identifies the endpoint of
+// the builder.
+func endpointAttribute(e *endpoint) attribute.KeyValue {
+	return attribute.KeyValue{Key: "endpoint", Value: attribute.StringValue(e.label)}
+}
+
+// labelKeys returns sorted names of all custom labels of the builders.
+func labelKeys(builders map[string]*builder, reference *builder) []string {
+	keys := make(map[string]struct{})
+	for _, b := range builders {
+		for key := range b.cfg.Labels {
+			keys[key] = struct{}{}
+		}
+	}
+	if reference != nil {
+		for key := range reference.cfg.Labels {
+			keys[key] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(keys))
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"slices"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// confirmMissing verifies the missing txs against the chain as seen by the
+// canonical node: the txs whose nonce was already used are re-classified as
+// either included (if there is a receipt for them) or replaced (otherwise).
+// The txs that could not be verified are still reported as missing.  The
+// nonce gaps are narrowed down to the nonces that are not used yet (and are
+// dropped if there are none), and the txs that are not known to any builder
+// are dropped once their nonces are used.
+func (s *Server) confirmMissing(ctx context.Context, canonical string, findings []*types.Finding) []*types.Finding {
+	l := logutils.LoggerFromContext(ctx)
+
+	var (
+		candidates = make([]*types.Finding, 0)
+		gaps       = make([]*types.Finding, 0)
+		unknown    = make([]*types.Finding, 0)
+		addresses  = make(map[string]struct{})
+	)
+	for _, f := range findings {
+		switch {
+		case f.Kind == types.FindingMissingTx && len(f.TxHashes) > 0:
+			candidates = append(candidates, f)
+		case f.Kind == types.FindingNonceGap:
+			gaps = append(gaps, f)
+		case f.Kind == types.FindingMissingTx, f.Kind == types.FindingUnknownTx:
+			unknown = append(unknown, f)
+		default:
+			continue
+		}
+		addresses[f.Address] = struct{}{}
+	}
+	if canonical == "" || len(addresses) == 0 {
+		return findings
+	}
+
+	list := make([]string, 0, len(addresses))
+	for addr := range addresses {
+		list = append(list, addr)
+	}
+	nonces := s.getNonces(ctx, canonical, nil, list)
+
+	dropped := make(map[*types.Finding]struct{})
+	for _, f := range gaps {
+		nonce, known := nonces[f.Address]
+		switch {
+		case !known || nonce <= f.Nonces.Start:
+			continue
+		case nonce > f.Nonces.End:
+			dropped[f] = struct{}{}
+		default:
+			f.Nonces = &types.NonceRange{Start: nonce, End: f.Nonces.End}
+		}
+	}
+	for _, f := range unknown {
+		if nonce, known := nonces[f.Address]; known && nonce > f.Nonces.End {
+			dropped[f] = struct{}{}
+		}
+	}
+	if len(dropped) > 0 {
+		findings = slices.DeleteFunc(findings, func(f *types.Finding) bool {
+			_, isDropped := dropped[f]
+			return isDropped
+		})
+	}
+
+	// the same tx is usually missing on more than one builder
+	used := make(map[string][]*types.Finding, len(candidates)) // tx hash -> findings
+	for _, f := range candidates {
+		if nonce, known := nonces[f.Address]; known && nonce > f.Nonces.Start {
+			used[f.TxHashes[0]] = append(used[f.TxHashes[0]], f)
+		}
+	}
+	if len(used) == 0 {
+		return findings
+	}
+
+	hashes := make([]string, 0, len(used))
+	for hash := range used {
+		hashes = append(hashes, hash)
+	}
+	receipts := s.getReceipts(ctx, canonical, hashes)
+
+	for hash, fs := range used {
+		receipt, known := receipts[hash]
+		if !known {
+			continue
+		}
+		for _, f := range fs {
+			reclassify(f, receipt)
+		}
+	}
+
+	l.Debug("Confirmed missing txs against the chain",
+		zap.String("canonical", canonical),
+		zap.Int("candidates", len(candidates)),
+		zap.Int("dropped", len(dropped)),
+		zap.Int("used_nonces", len(used)),
+	)
+
+	return findings
+}
+
+// reclassify turns the missing tx (which nonce is already used on chain) into
+// either included (if there is a receipt for it) or replaced one.
+func reclassify(f *types.Finding, receipt *jrpc.EthTxReceipt) {
+	if receipt != nil {
+		f.Kind = types.FindingIncludedTx
+		f.Severity = types.SeverityDebug
+		f.Message = "Tx is not known to the builder, but is already included"
+		f.BlockNumber = uint64(receipt.BlockNumber)
+		f.BlockHashes = []string{receipt.BlockHash}
+		return
+	}
+
+	f.Kind = types.FindingReplacedTx
+	f.Severity = types.SeverityDebug
+	f.Message = "Tx is not known to the builder, but its nonce is already used by another one"
+}
+
+// getReceipts returns the receipts of the txs (nil for the txs that are not
+// included).  The txs which receipts could not be fetched are omitted.
+func (s *Server) getReceipts(ctx context.Context, builder string, hashes []string) map[string]*jrpc.EthTxReceipt {
+	l := logutils.LoggerFromContext(ctx)
+
+	res := make(map[string]*jrpc.EthTxReceipt, len(hashes))
+
+	for start := 0; start < len(hashes); start += s.cfg.Monitor.NonceBatchSize {
+		end := min(start+s.cfg.Monitor.NonceBatchSize, len(hashes))
+
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for _, hash := range hashes[start:end] {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hash},
+				Result: new(*jrpc.EthTxReceipt),
+			})
+		}
+
+		if err := s.batchCall(ctx, builder, batch); err != nil {
+			l.Warn("Failed to get tx receipts",
+				zap.Error(err),
+				zap.String("builder", builder),
+				zap.Int("count", len(batch)),
+			)
+			continue
+		}
+
+		for idx, elem := range batch {
+			if elem.Error != nil {
+				l.Warn("Failed to get tx receipt",
+					zap.Error(elem.Error),
+					zap.String("builder", builder),
+					zap.String("hash", hashes[start+idx]),
+				)
+				continue
+			}
+			res[hashes[start+idx]] = *elem.Result.(**jrpc.EthTxReceipt)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// testCanonical serves confirmed nonces and tx receipts via json-rpc.
+type testCanonical struct {
+	nonces   map[common.Address]uint64
+	receipts map[string]*jrpc.EthTxReceipt
+}
+
+func (c *testCanonical) GetTransactionCount(addr common.Address, _ string) hexutil.Uint64 {
+	return hexutil.Uint64(c.nonces[addr])
+}
+
+func (c *testCanonical) GetTransactionReceipt(hash string) *jrpc.EthTxReceipt {
+	return c.receipts[hash]
+}
+
+func TestConfirmMissing(t *testing.T) {
+	const (
+		addr  = "0x0000000000000000000000000000000000000001"
+		other = "0x0000000000000000000000000000000000000002"
+	)
+
+	missing := func(builder, addr string, nonce uint64, hash string) *types.Finding {
+		return &types.Finding{
+			Kind:     types.FindingMissingTx,
+			Severity: types.SeverityWarning,
+			Builder:  builder,
+			Address:  addr,
+			Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+			TxHashes: []string{hash},
+		}
+	}
+	unknown := func(builder, addr string, nonce uint64) *types.Finding {
+		kind := types.FindingMissingTx
+		if builder == "" {
+			kind = types.FindingUnknownTx
+		}
+		return &types.Finding{
+			Kind:     kind,
+			Severity: types.SeverityWarning,
+			Builder:  builder,
+			Address:  addr,
+			Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+		}
+	}
+	gap := func(builder, addr string, start, end uint64) *types.Finding {
+		return &types.Finding{
+			Kind:     types.FindingNonceGap,
+			Severity: types.SeverityWarning,
+			Builder:  builder,
+			Address:  addr,
+			Nonces:   &types.NonceRange{Start: start, End: end},
+		}
+	}
+
+	type want struct {
+		builder string
+		kind    types.FindingKind
+		nonces  types.NonceRange
+	}
+
+	for _, tc := range []struct {
+		name     string
+		nonces   map[string]uint64
+		receipts []string // hashes of included txs
+		findings []*types.Finding
+		want     []want
+	}{
+		{
+			name:     "nonce not used yet",
+			nonces:   map[string]uint64{addr: 5},
+			findings: []*types.Finding{missing("b1", addr, 5, "0xaa")},
+			want:     []want{{"b1", types.FindingMissingTx, types.NonceRange{Start: 5, End: 5}}},
+		},
+		{
+			name:     "included tx",
+			nonces:   map[string]uint64{addr: 6},
+			receipts: []string{"0xaa"},
+			findings: []*types.Finding{missing("b1", addr, 5, "0xaa")},
+			want:     []want{{"b1", types.FindingIncludedTx, types.NonceRange{Start: 5, End: 5}}},
+		},
+		{
+			name:     "replaced tx",
+			nonces:   map[string]uint64{addr: 6},
+			findings: []*types.Finding{missing("b1", addr, 5, "0xaa")},
+			want:     []want{{"b1", types.FindingReplacedTx, types.NonceRange{Start: 5, End: 5}}},
+		},
+		{
+			name:     "tx missing on several builders",
+			nonces:   map[string]uint64{addr: 6},
+			receipts: []string{"0xaa"},
+			findings: []*types.Finding{
+				missing("b1", addr, 5, "0xaa"),
+				missing("b2", addr, 5, "0xaa"),
+				missing("b3", addr, 5, "0xaa"),
+			},
+			want: []want{
+				{"b1", types.FindingIncludedTx, types.NonceRange{Start: 5, End: 5}},
+				{"b2", types.FindingIncludedTx, types.NonceRange{Start: 5, End: 5}},
+				{"b3", types.FindingIncludedTx, types.NonceRange{Start: 5, End: 5}},
+			},
+		},
+		{
+			name:     "accounts are confirmed separately",
+			nonces:   map[string]uint64{addr: 6, other: 5},
+			receipts: []string{"0xaa"},
+			findings: []*types.Finding{
+				missing("b1", addr, 5, "0xaa"),
+				missing("b1", other, 5, "0xbb"),
+			},
+			want: []want{
+				{"b1", types.FindingIncludedTx, types.NonceRange{Start: 5, End: 5}},
+				{"b1", types.FindingMissingTx, types.NonceRange{Start: 5, End: 5}},
+			},
+		},
+		{
+			name:     "nonce gap is narrowed down",
+			nonces:   map[string]uint64{addr: 7},
+			findings: []*types.Finding{gap("b1", addr, 5, 9)},
+			want:     []want{{"b1", types.FindingNonceGap, types.NonceRange{Start: 7, End: 9}}},
+		},
+		{
+			name:     "nonce gap is kept",
+			nonces:   map[string]uint64{addr: 5},
+			findings: []*types.Finding{gap("b1", addr, 5, 9)},
+			want:     []want{{"b1", types.FindingNonceGap, types.NonceRange{Start: 5, End: 9}}},
+		},
+		{
+			name:     "nonce gap is closed",
+			nonces:   map[string]uint64{addr: 10},
+			findings: []*types.Finding{gap("b1", addr, 5, 9)},
+		},
+		{
+			name:   "unknown txs with used nonces are dropped along with the gap",
+			nonces: map[string]uint64{addr: 7},
+			findings: []*types.Finding{
+				unknown("b1", addr, 5),
+				unknown("b1", addr, 6),
+				unknown("b1", addr, 7),
+				gap("b1", addr, 5, 7),
+				unknown("", addr, 5),
+				unknown("", addr, 6),
+				unknown("", addr, 7),
+			},
+			want: []want{
+				{"b1", types.FindingMissingTx, types.NonceRange{Start: 7, End: 7}},
+				{"b1", types.FindingNonceGap, types.NonceRange{Start: 7, End: 7}},
+				{"", types.FindingUnknownTx, types.NonceRange{Start: 7, End: 7}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			canonical := &testCanonical{
+				nonces:   make(map[common.Address]uint64, len(tc.nonces)),
+				receipts: make(map[string]*jrpc.EthTxReceipt, len(tc.receipts)),
+			}
+			for addr, nonce := range tc.nonces {
+				canonical.nonces[common.HexToAddress(addr)] = nonce
+			}
+			for _, hash := range tc.receipts {
+				canonical.receipts[hash] = &jrpc.EthTxReceipt{TxHash: hash, BlockHash: "0xbb", BlockNumber: 10}
+			}
+			s := newTestServer(t, "ref")
+			serveTestBuilder(t, s, &config.Builder{Name: "ref"}, map[string]any{"eth": canonical})
+
+			findings := s.confirmMissing(context.Background(), "ref", slices.Clone(tc.findings))
+
+			got := make([]want, 0, len(findings))
+			for _, f := range findings {
+				got = append(got, want{f.Builder, f.Kind, *f.Nonces})
+				if f.Kind == types.FindingIncludedTx && (f.BlockNumber != 10 || !slices.Equal(f.BlockHashes, []string{"0xbb"})) {
+					t.Errorf("included tx of %s at %d %v, want at 10 [0xbb]", f.Builder, f.BlockNumber, f.BlockHashes)
+				}
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("confirmMissing() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
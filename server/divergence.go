@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"slices"
+
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+)
+
+// analyseDivergence compares the txpools of each pair of builders: how many
+// txs of the other builder are absent from the builder's txpool, and how
+// similar the two txpools are (jaccard index).  The holders are the builders
+// of each tx from the merged txpool index (tx hash -> builders).
+func (s *Server) analyseDivergence(
+	ctx context.Context,
+	status map[string]*types.BuilderStatus,
+	holders map[string][]string,
+) map[string]map[string]*types.DivergenceReport {
+	builders := make([]string, 0, len(status))
+	for builder, sts := range status {
+		if sts.Txpool == nil {
+			attr := attribute.KeyValue{Key: "builder", Value: attribute.StringValue(builder)}
+			other := attribute.KeyValue{Key: "other", Value: attribute.StringValue(builder)}
+			metrics.TxpoolDivergenceTxCount.Forget(attr)
+			metrics.TxpoolDivergenceTxCount.Forget(other)
+			metrics.TxpoolSimilarity.Forget(attr)
+			metrics.TxpoolSimilarity.Forget(other)
+			continue
+		}
+		builders = append(builders, builder)
+	}
+
+	var (
+		sizes   = make(map[string]int, len(builders))            // builder -> count of txs
+		missing = make(map[string]map[string]int, len(builders)) // builder -> other -> count of other's txs absent from builder
+		shared  = make(map[string]map[string]int, len(builders)) // builder -> other -> count of txs they both have
+	)
+	for _, builder := range builders {
+		missing[builder] = make(map[string]int, len(builders))
+		shared[builder] = make(map[string]int, len(builders))
+	}
+
+	for _, has := range holders {
+		for _, other := range has {
+			sizes[other]++
+			for _, builder := range builders {
+				if builder == other {
+					continue
+				}
+				if slices.Contains(has, builder) {
+					shared[builder][other]++
+				} else {
+					missing[builder][other]++
+				}
+			}
+		}
+	}
+
+	res := make(map[string]map[string]*types.DivergenceReport, len(builders))
+	for _, builder := range builders {
+		res[builder] = make(map[string]*types.DivergenceReport, len(builders)-1)
+		for _, other := range builders {
+			if other == builder {
+				continue
+			}
+
+			similarity := 1.0 // two empty txpools are the same
+			if union := sizes[builder] + sizes[other] - shared[builder][other]; union > 0 {
+				similarity = float64(shared[builder][other]) / float64(union)
+			}
+
+			res[builder][other] = &types.DivergenceReport{
+				MissingTxs: missing[builder][other],
+				Similarity: similarity,
+			}
+
+			attrs := s.builderAttributes(builder,
+				attribute.KeyValue{Key: "other", Value: attribute.StringValue(other)},
+			)
+			metrics.TxpoolDivergenceTxCount.Record(ctx, int64(missing[builder][other]), otelapi.WithAttributes(attrs...))
+			metrics.TxpoolSimilarity.Record(ctx, similarity, otelapi.WithAttributes(attrs...))
+		}
+	}
+
+	return res
+}
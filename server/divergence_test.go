@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+func TestAnalyseDivergence(t *testing.T) {
+	type pair struct {
+		builder, other string
+	}
+
+	for _, tc := range []struct {
+		name    string
+		txpools map[string][]string // builder -> tx hashes (nil means no txpool)
+		want    map[pair]types.DivergenceReport
+	}{
+		{
+			name: "empty txpools are the same",
+			txpools: map[string][]string{
+				"a": {},
+				"b": {},
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 0, Similarity: 1},
+				{"b", "a"}: {MissingTxs: 0, Similarity: 1},
+			},
+		},
+		{
+			name: "identical txpools",
+			txpools: map[string][]string{
+				"a": {"t1", "t2"},
+				"b": {"t1", "t2"},
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 0, Similarity: 1},
+				{"b", "a"}: {MissingTxs: 0, Similarity: 1},
+			},
+		},
+		{
+			name: "subset",
+			txpools: map[string][]string{
+				"a": {"t1", "t2", "t3"},
+				"b": {"t1", "t2"},
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 0, Similarity: 2.0 / 3},
+				{"b", "a"}: {MissingTxs: 1, Similarity: 2.0 / 3},
+			},
+		},
+		{
+			name: "disjoint txpools",
+			txpools: map[string][]string{
+				"a": {"t1"},
+				"b": {"t2", "t3"},
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 2, Similarity: 0},
+				{"b", "a"}: {MissingTxs: 1, Similarity: 0},
+			},
+		},
+		{
+			name: "three builders",
+			txpools: map[string][]string{
+				"a": {"t1", "t2"},
+				"b": {"t2", "t3"},
+				"c": {"t1", "t2", "t3"},
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 1, Similarity: 1.0 / 3},
+				{"a", "c"}: {MissingTxs: 1, Similarity: 2.0 / 3},
+				{"b", "a"}: {MissingTxs: 1, Similarity: 1.0 / 3},
+				{"b", "c"}: {MissingTxs: 1, Similarity: 2.0 / 3},
+				{"c", "a"}: {MissingTxs: 0, Similarity: 2.0 / 3},
+				{"c", "b"}: {MissingTxs: 0, Similarity: 2.0 / 3},
+			},
+		},
+		{
+			name: "builders without txpool are skipped",
+			txpools: map[string][]string{
+				"a": {"t1"},
+				"b": {"t1"},
+				"c": nil,
+			},
+			want: map[pair]types.DivergenceReport{
+				{"a", "b"}: {MissingTxs: 0, Similarity: 1},
+				{"b", "a"}: {MissingTxs: 0, Similarity: 1},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, slices.Collect(maps.Keys(tc.txpools))...)
+
+			var (
+				status  = make(map[string]*types.BuilderStatus, len(tc.txpools))
+				holders = make(map[string][]string)
+			)
+			for builder, hashes := range tc.txpools {
+				if hashes == nil {
+					status[builder] = &types.BuilderStatus{}
+					continue
+				}
+				status[builder] = &types.BuilderStatus{Txpool: &jrpc.TxpoolContent{}}
+				for _, hash := range hashes {
+					holders[hash] = append(holders[hash], builder)
+				}
+			}
+
+			got := s.analyseDivergence(context.Background(), status, holders)
+
+			count := 0
+			for builder, others := range got {
+				for other, report := range others {
+					count++
+					want, expected := tc.want[pair{builder, other}]
+					switch {
+					case !expected:
+						t.Errorf("unexpected report for %s vs %s: %+v", builder, other, report)
+					case report.MissingTxs != want.MissingTxs:
+						t.Errorf("%s vs %s: missing txs = %d, want %d", builder, other, report.MissingTxs, want.MissingTxs)
+					case math.Abs(report.Similarity-want.Similarity) > 1e-9:
+						t.Errorf("%s vs %s: similarity = %f, want %f", builder, other, report.Similarity, want.Similarity)
+					}
+				}
+			}
+			if count != len(tc.want) {
+				t.Errorf("got %d reports, want %d", count, len(tc.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/utils"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+const (
+	// endpointCooldown is how long the failed endpoint is tried only after
+	// the healthy ones
+	endpointCooldown = time.Minute
+)
+
+// failover invokes the function against the endpoints of the builder (each
+// attempt with its own timeout) until it succeeds.  The endpoints are tried
+// in their preferred order, and the next one is tried only if the failure was
+// caused by the endpoint being unreachable (as opposed to, for example, json
+// rpc error reported by the node).
+func (s *Server) failover(ctx context.Context, name string, fn func(context.Context, *endpoint) error) error {
+	return s.builder(name).failover(ctx, s.timeout(name), fn)
+}
+
+// failover invokes the function against the endpoints of the builder until
+// it succeeds (see Server.failover).
+func (b *builder) failover(ctx context.Context, timeout time.Duration, fn func(context.Context, *endpoint) error) error {
+	l := logutils.LoggerFromContext(ctx)
+
+	name := b.cfg.Name
+	order := b.order()
+
+	var err error
+	for attempt, idx := range order {
+		e := b.endpoints[idx]
+
+		_ctx, cancel := context.WithTimeout(ctx, timeout)
+		err = redactError(fn(_ctx, e))
+		cancel()
+
+		if err == nil {
+			e.failedAt.Store(0)
+			if prev := b.active.Swap(int64(idx)); prev != int64(idx) {
+				l.Info("Switched builder's active endpoint",
+					zap.String("builder", name),
+					zap.String("endpoint", e.label),
+				)
+			}
+			return nil
+		}
+
+		if !isEndpointFailure(err) || ctx.Err() != nil {
+			return err
+		}
+		e.failedAt.Store(time.Now().UnixNano())
+
+		if attempt < len(order)-1 {
+			l.Warn("Builder's endpoint has failed, trying the next one",
+				zap.Error(err),
+				zap.String("builder", name),
+				zap.String("endpoint", e.label),
+			)
+		}
+	}
+
+	return err
+}
+
+// isEndpointFailure returns true if the error means that the endpoint could
+// not serve the request (so that the other one should be tried).
+func isEndpointFailure(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	return utils.ClassifyRPCError(err) != utils.RPCErrorDecodeError
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testRPCError is json-rpc error reported by the node.
+type testRPCError struct{}
+
+func (testRPCError) Error() string  { return "method not found" }
+func (testRPCError) ErrorCode() int { return -32601 }
+
+func TestIsEndpointFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: context.DeadlineExceeded, want: true},
+		{name: "connection refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: true},
+		{name: "http error", err: rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, want: true},
+		{name: "other", err: errors.New("unexpected eof"), want: true},
+		{name: "json-rpc error", err: testRPCError{}},
+		{name: "wrapped json-rpc error", err: fmt.Errorf("txpool: %w", testRPCError{})},
+		{name: "decode error", err: &json.SyntaxError{Offset: 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isEndpointFailure(tc.err); got != tc.want {
+				t.Errorf("isEndpointFailure(%v) = %t, want %t", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilderOrder(t *testing.T) {
+	var (
+		recently = time.Now().Add(-endpointCooldown / 2).UnixNano()
+		long     = time.Now().Add(-2 * endpointCooldown).UnixNano()
+	)
+
+	for _, tc := range []struct {
+		name     string
+		failedAt []int64
+		want     []int
+	}{
+		{
+			name:     "all healthy",
+			failedAt: []int64{0, 0, 0},
+			want:     []int{0, 1, 2},
+		},
+		{
+			name:     "recently failed is tried last",
+			failedAt: []int64{recently, 0, 0},
+			want:     []int{1, 2, 0},
+		},
+		{
+			name:     "failed ones keep configured order",
+			failedAt: []int64{recently, 0, recently},
+			want:     []int{1, 0, 2},
+		},
+		{
+			name:     "cooldown is over",
+			failedAt: []int64{long, 0, recently},
+			want:     []int{0, 1, 2},
+		},
+		{
+			name:     "all failed",
+			failedAt: []int64{recently, recently},
+			want:     []int{0, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &builder{}
+			for _, failedAt := range tc.failedAt {
+				e := &endpoint{}
+				e.failedAt.Store(failedAt)
+				b.endpoints = append(b.endpoints, e)
+			}
+			if got := b.order(); !slices.Equal(got, tc.want) {
+				t.Errorf("order() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilderFailover(t *testing.T) {
+	unreachable := fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+
+	for _, tc := range []struct {
+		name       string
+		failing    []bool             // which endpoints have failed recently
+		errs       map[string][]error // endpoint -> results of consecutive calls (nil once exhausted)
+		calls      int
+		wantTried  []string
+		wantErr    error
+		wantActive int
+		wantFailed []bool
+	}{
+		{
+			name:       "first endpoint succeeds",
+			failing:    []bool{false, false},
+			calls:      1,
+			wantTried:  []string{"e0"},
+			wantActive: 0,
+			wantFailed: []bool{false, false},
+		},
+		{
+			name:       "unreachable endpoint is failed over",
+			failing:    []bool{false, false},
+			errs:       map[string][]error{"e0": {unreachable}},
+			calls:      1,
+			wantTried:  []string{"e0", "e1"},
+			wantActive: 1,
+			wantFailed: []bool{true, false},
+		},
+		{
+			name:       "failed endpoint is tried last on the next call",
+			failing:    []bool{false, false},
+			errs:       map[string][]error{"e0": {unreachable}},
+			calls:      2,
+			wantTried:  []string{"e0", "e1", "e1"},
+			wantActive: 1,
+			wantFailed: []bool{true, false},
+		},
+		{
+			name:       "json-rpc error is not failed over",
+			failing:    []bool{false, false},
+			errs:       map[string][]error{"e0": {testRPCError{}}},
+			calls:      1,
+			wantTried:  []string{"e0"},
+			wantErr:    testRPCError{},
+			wantActive: 0,
+			wantFailed: []bool{false, false},
+		},
+		{
+			name:       "all endpoints are unreachable",
+			failing:    []bool{false, false},
+			errs:       map[string][]error{"e0": {unreachable}, "e1": {unreachable}},
+			calls:      1,
+			wantTried:  []string{"e0", "e1"},
+			wantErr:    syscall.ECONNREFUSED,
+			wantActive: 0,
+			wantFailed: []bool{true, true},
+		},
+		{
+			name:       "recovered endpoint is healthy again",
+			failing:    []bool{true, false},
+			errs:       map[string][]error{"e1": {unreachable}},
+			calls:      1,
+			wantTried:  []string{"e1", "e0"},
+			wantActive: 0,
+			wantFailed: []bool{false, true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &builder{cfg: &config.Builder{Name: "b0"}}
+			for idx, failing := range tc.failing {
+				e := &endpoint{label: fmt.Sprintf("e%d", idx)}
+				if failing {
+					e.failedAt.Store(time.Now().UnixNano())
+				}
+				b.endpoints = append(b.endpoints, e)
+			}
+
+			var (
+				tried []string
+				err   error
+			)
+			for range tc.calls {
+				err = b.failover(context.Background(), time.Second, func(_ context.Context, e *endpoint) error {
+					tried = append(tried, e.label)
+					if errs := tc.errs[e.label]; len(errs) > 0 {
+						tc.errs[e.label] = errs[1:]
+						return errs[0]
+					}
+					return nil
+				})
+			}
+
+			if !slices.Equal(tried, tc.wantTried) {
+				t.Errorf("tried %v, want %v", tried, tc.wantTried)
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("failover() = %v, want %v", err, tc.wantErr)
+			}
+			if active := int(b.active.Load()); active != tc.wantActive {
+				t.Errorf("active endpoint %d, want %d", active, tc.wantActive)
+			}
+			for idx, e := range b.endpoints {
+				if failed := e.failedAt.Load() != 0; failed != tc.wantFailed[idx] {
+					t.Errorf("endpoint %s failed = %t, want %t", e.label, failed, tc.wantFailed[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+type findingsSeries struct {
+	builder  string
+	kind     types.FindingKind
+	severity types.FindingSeverity
+}
+
+// report logs the findings of the monitoring pass and derives the metrics
+// from them.
+func (s *Server) report(ctx context.Context, ts time.Time, status map[string]*types.BuilderStatus, findings []*types.Finding) {
+	l := logutils.LoggerFromContext(ctx)
+
+	{ // keep track of when the findings were first seen
+		seen := make(map[string]*types.Finding, len(findings))
+		for _, f := range findings {
+			id := f.ID()
+			f.FirstSeen, f.LastSeen = ts, ts
+			if prev, known := s.findings[id]; known {
+				f.FirstSeen = prev.FirstSeen
+			}
+			seen[id] = f
+		}
+		s.findings = seen
+	}
+
+	var (
+		counts          = make(map[findingsSeries]int64)
+		forks           = make(map[uint64]bool) // height -> whether the fork is new
+		includedTx      = make(map[string]int64)
+		missingTx       = make(map[string]int64)
+		nonceGaps       = make(map[string]int64)
+		nonceMismatches = make(map[string]int64)
+		replacedTx      = make(map[string]int64)
+		staleTx         = make(map[string]int64)
+		stuckTx         = make(map[string]int64)
+		unknownTx       = int64(0)
+	)
+
+	for _, f := range findings {
+		if f.Builder != "" {
+			f.Labels = s.labels(f.Builder)
+		}
+		logFinding(l, f)
+
+		counts[findingsSeries{builder: f.Builder, kind: f.Kind, severity: f.Severity}]++
+
+		switch f.Kind {
+		case types.FindingChainFork:
+			// the fork is counted once, in the pass where it was first seen
+			isNew, known := forks[f.BlockNumber]
+			forks[f.BlockNumber] = (isNew || !known) && f.FirstSeen.Equal(f.LastSeen)
+
+		case types.FindingDuplicateNonce:
+			metrics.TxpoolDuplicateNonceCount.Add(ctx, 1, otelapi.WithAttributes(
+				attribute.KeyValue{Key: "from", Value: attribute.StringValue(f.Address)},
+			))
+
+		case types.FindingIncludedTx:
+			includedTx[f.Builder]++
+
+		case types.FindingMissingTx:
+			missingTx[f.Builder]++
+
+		case types.FindingNonceGap:
+			nonceGaps[f.Builder] += int64(f.Nonces.Length())
+
+		case types.FindingNonceMismatch:
+			nonceMismatches[f.Builder]++
+
+		case types.FindingReplacedTx:
+			replacedTx[f.Builder]++
+
+		case types.FindingStaleTx:
+			staleTx[f.Builder] += int64(len(f.TxHashes))
+
+		case types.FindingStuckTx:
+			stuckTx[f.Builder]++
+
+		case types.FindingReorg:
+			metrics.ReorgDepth.Record(ctx, int64(f.Depth), otelapi.WithAttributes(
+				s.builderAttributes(f.Builder)...,
+			))
+
+		case types.FindingUnknownTx:
+			unknownTx++
+		}
+	}
+
+	newForks := int64(0)
+	for _, isNew := range forks {
+		if isNew {
+			newForks++
+		}
+	}
+	if newForks > 0 {
+		metrics.ChainForkCount.Add(ctx, newForks)
+	}
+
+	for builder, sts := range status {
+		attrs := s.builderAttributes(builder)
+
+		if sts.Txpool == nil {
+			metrics.TxpoolNonceGapsLength.Forget(attrs...)
+			metrics.TxpoolMissingTxCount.Forget(attrs...)
+			metrics.TxpoolIncludedTxCount.Forget(attrs...)
+			metrics.TxpoolReplacedTxCount.Forget(attrs...)
+			metrics.TxpoolStaleTxCount.Forget(attrs...)
+			metrics.TxpoolStuckTxCount.Forget(attrs...)
+			metrics.AccountNonceMismatchCount.Forget(attrs...)
+			metrics.TxpoolPendingTxCount.Forget(attrs...)
+			metrics.TxpoolQueuedTxCount.Forget(attrs...)
+			continue
+		}
+
+		var pending, queued int64
+		for _, nonces := range sts.Txpool.Pending {
+			pending += int64(len(nonces))
+		}
+		for _, nonces := range sts.Txpool.Queued {
+			queued += int64(len(nonces))
+		}
+		metrics.TxpoolPendingTxCount.Record(ctx, pending, otelapi.WithAttributes(attrs...))
+		metrics.TxpoolQueuedTxCount.Record(ctx, queued, otelapi.WithAttributes(attrs...))
+
+		metrics.TxpoolNonceGapsLength.Record(ctx, nonceGaps[builder], otelapi.WithAttributes(attrs...))
+		metrics.TxpoolMissingTxCount.Record(ctx, missingTx[builder], otelapi.WithAttributes(attrs...))
+		metrics.TxpoolIncludedTxCount.Record(ctx, includedTx[builder], otelapi.WithAttributes(attrs...))
+		metrics.TxpoolReplacedTxCount.Record(ctx, replacedTx[builder], otelapi.WithAttributes(attrs...))
+		metrics.TxpoolStaleTxCount.Record(ctx, staleTx[builder], otelapi.WithAttributes(attrs...))
+		metrics.TxpoolStuckTxCount.Record(ctx, stuckTx[builder], otelapi.WithAttributes(attrs...))
+		metrics.AccountNonceMismatchCount.Record(ctx, nonceMismatches[builder], otelapi.WithAttributes(attrs...))
+	}
+
+	metrics.TxpoolUnknownTxCount.Record(ctx, unknownTx)
+
+	{ // report zeroes for the series that were seen before but not now
+		for series := range counts {
+			s.findingsSeries[series] = struct{}{}
+		}
+		for series := range s.findingsSeries {
+			metrics.FindingsCount.Record(ctx, counts[series], otelapi.WithAttributes(s.builderAttributes(series.builder,
+				attribute.KeyValue{Key: "kind", Value: attribute.StringValue(string(series.kind))},
+				attribute.KeyValue{Key: "severity", Value: attribute.StringValue(string(series.severity))},
+			)...))
+		}
+	}
+}
+
+func logFinding(l *zap.Logger, f *types.Finding) {
+	fields := []zap.Field{
+		zap.String("kind", string(f.Kind)),
+	}
+	if f.Builder != "" {
+		fields = append(fields, zap.String("builder", f.Builder))
+	}
+	if len(f.Labels) > 0 {
+		fields = append(fields, zap.Any("labels", f.Labels))
+	}
+	if f.Other != "" {
+		fields = append(fields, zap.String("other", f.Other))
+	}
+	if f.Address != "" {
+		fields = append(fields, zap.String("from", f.Address))
+	}
+	if f.Nonces != nil {
+		fields = append(fields,
+			zap.Uint64("nonce_start", f.Nonces.Start),
+			zap.Uint64("nonce_end", f.Nonces.End),
+		)
+	}
+	if len(f.TxHashes) > 0 {
+		fields = append(fields, zap.Strings("tx_hashes", f.TxHashes))
+	}
+	if f.BlockNumber != 0 {
+		fields = append(fields, zap.Uint64("block_number", f.BlockNumber))
+	}
+	if len(f.BlockHashes) > 0 {
+		fields = append(fields, zap.Strings("block_hashes", f.BlockHashes))
+	}
+	if f.Depth != 0 {
+		fields = append(fields, zap.Int("depth", f.Depth))
+	}
+	fields = append(fields,
+		zap.Time("first_seen", f.FirstSeen),
+		zap.Time("last_seen", f.LastSeen),
+	)
+
+	switch f.Severity {
+	case types.SeverityDebug:
+		l.Debug(f.Message, fields...)
+	case types.SeverityInfo:
+		l.Info(f.Message, fields...)
+	case types.SeverityWarning:
+		l.Warn(f.Message, fields...)
+	default:
+		l.Error(f.Message, fields...)
+	}
+}
@@ -1,7 +1,81 @@
 package server
 
-import "net/http"
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/flashbots/bmonitor/logutils"
+	"go.uber.org/zap"
+)
 
 func (s *Server) handleHealthcheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := s.last.Load()
+	if report == nil {
+		http.Error(w, "no monitoring pass has completed yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logutils.LoggerFromRequest(r).Error("Failed to encode the status report",
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *Server) handleTx(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := s.txs.report(strings.ToLower(r.PathValue("hash")))
+	if report == nil {
+		http.Error(w, "tx is not known", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logutils.LoggerFromRequest(r).Error("Failed to encode the tx report",
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, _ := strings.CutPrefix(r.Header.Get("authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Server.AdminToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	l := logutils.LoggerFromRequest(r)
+	l.Info("Reload requested via api; reloading configuration...")
+
+	if err := s.reload(logutils.ContextWithLogger(r.Context(), l)); err != nil {
+		l.Error("Failed to reload configuration",
+			zap.Error(err),
+		)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
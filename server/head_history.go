@@ -0,0 +1,83 @@
+package server
+
+const (
+	headHistorySize = 64
+)
+
+// headHistory is a ring of the recent block hashes that a builder has
+// reported as canonical.
+type headHistory struct {
+	blocks  []headHistoryBlock
+	filled  bool
+	highest uint64
+	lowest  uint64
+	source  string // endpoint that reported the heads
+}
+
+type headHistoryBlock struct {
+	number uint64
+	hash   string
+}
+
+func newHeadHistory(size int) *headHistory {
+	return &headHistory{
+		blocks: make([]headHistoryBlock, size),
+	}
+}
+
+func (h *headHistory) size() uint64 {
+	return uint64(len(h.blocks))
+}
+
+// get returns the hash recorded at the given height (if there is one).
+func (h *headHistory) get(number uint64) (string, bool) {
+	if !h.filled || number < h.lowest || number > h.highest {
+		return "", false
+	}
+	block := h.blocks[number%h.size()]
+	if block.hash == "" || block.number != number {
+		return "", false
+	}
+	return block.hash, true
+}
+
+// put records the hash at the given height.  Heights that fall out of the
+// window of the ring are forgotten.
+func (h *headHistory) put(number uint64, hash string) {
+	switch {
+	case !h.filled:
+		h.filled = true
+		h.highest, h.lowest = number, number
+	case number > h.highest:
+		h.highest = number
+		if h.highest-h.lowest >= h.size() {
+			h.lowest = h.highest - h.size() + 1
+		}
+	case number < h.lowest:
+		if h.highest-number >= h.size() {
+			return
+		}
+		h.lowest = number
+	}
+	h.blocks[number%h.size()] = headHistoryBlock{number: number, hash: hash}
+}
+
+// truncate forgets all heights above the given one and returns the count of
+// the hashes that were known there.
+func (h *headHistory) truncate(number uint64) int {
+	if !h.filled || number >= h.highest {
+		return 0
+	}
+	count := 0
+	for n := max(number+1, h.lowest); n <= h.highest; n++ {
+		if _, known := h.get(n); known {
+			count++
+		}
+	}
+	if number < h.lowest {
+		*h = *newHeadHistory(len(h.blocks))
+		return count
+	}
+	h.highest = number
+	return count
+}
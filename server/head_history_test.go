@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHeadHistory(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		size     int
+		puts     []uint64
+		truncate uint64 // 0 means no truncation
+		want     []uint64
+		wantNot  []uint64
+		wantCut  int
+	}{
+		{
+			name:    "empty",
+			size:    4,
+			wantNot: []uint64{0, 1, 10},
+		},
+		{
+			name:    "single head",
+			size:    4,
+			puts:    []uint64{10},
+			want:    []uint64{10},
+			wantNot: []uint64{9, 11},
+		},
+		{
+			name:    "window slides with new heads",
+			size:    4,
+			puts:    []uint64{10, 11, 12, 13, 14, 15},
+			want:    []uint64{12, 13, 14, 15},
+			wantNot: []uint64{10, 11, 16},
+		},
+		{
+			name:    "heads skipped ahead are unknown",
+			size:    4,
+			puts:    []uint64{10, 12},
+			want:    []uint64{10, 12},
+			wantNot: []uint64{11},
+		},
+		{
+			name:    "lower height within the window is recorded",
+			size:    4,
+			puts:    []uint64{12, 11},
+			want:    []uint64{11, 12},
+			wantNot: []uint64{10},
+		},
+		{
+			name:    "lower height outside the window is ignored",
+			size:    4,
+			puts:    []uint64{10, 11, 12, 13, 9},
+			want:    []uint64{10, 11, 12, 13},
+			wantNot: []uint64{9},
+		},
+		{
+			name:     "truncate forgets the heights above",
+			size:     4,
+			puts:     []uint64{10, 11, 12, 13},
+			truncate: 11,
+			want:     []uint64{10, 11},
+			wantNot:  []uint64{12, 13},
+			wantCut:  2,
+		},
+		{
+			name:     "truncate at the highest is no-op",
+			size:     4,
+			puts:     []uint64{10, 11},
+			truncate: 11,
+			want:     []uint64{10, 11},
+		},
+		{
+			name:     "truncate below the lowest forgets everything",
+			size:     4,
+			puts:     []uint64{10, 11, 12, 13},
+			truncate: 5,
+			wantNot:  []uint64{5, 10, 11, 12, 13},
+			wantCut:  4,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHeadHistory(tc.size)
+			for _, number := range tc.puts {
+				h.put(number, hashOf(number))
+			}
+
+			if tc.truncate != 0 {
+				if cut := h.truncate(tc.truncate); cut != tc.wantCut {
+					t.Errorf("truncate(%d) = %d, want %d", tc.truncate, cut, tc.wantCut)
+				}
+			}
+
+			for _, number := range tc.want {
+				if hash, known := h.get(number); !known || hash != hashOf(number) {
+					t.Errorf("get(%d) = %q, %t, want %q, true", number, hash, known, hashOf(number))
+				}
+			}
+			for _, number := range tc.wantNot {
+				if hash, known := h.get(number); known {
+					t.Errorf("get(%d) = %q, true, want unknown", number, hash)
+				}
+			}
+		})
+	}
+}
+
+func hashOf(number uint64) string {
+	return fmt.Sprintf("0x%x", number)
+}
@@ -2,38 +2,115 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flashbots/bmonitor/config"
 	"github.com/flashbots/bmonitor/jrpc"
 	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/metrics"
 	"github.com/flashbots/bmonitor/types"
 	"github.com/flashbots/bmonitor/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 )
 
+func (s *Server) loop(ctx context.Context) {
+	l := logutils.LoggerFromContext(ctx)
+
+	var (
+		cancel  context.CancelFunc
+		done    = make(chan struct{})
+		next    time.Time
+		queued  bool
+		running bool
+	)
+
+	start := func(ts time.Time) {
+		var _ctx context.Context
+		_ctx, cancel = context.WithCancel(ctx)
+		running = true
+		go func() {
+			s.monitor(_ctx, ts)
+			done <- struct{}{}
+		}()
+	}
+
+	ticks := s.ticks(ctx)
+
+	for {
+		select {
+		case ts := <-ticks:
+			if !running {
+				start(ts)
+				continue
+			}
+
+			action := s.cfg.Monitor.OverlapPolicy
+			switch action {
+			case config.OverlapPolicyCancel:
+				cancel()
+				next, queued = ts, true
+			case config.OverlapPolicyQueue:
+				if queued {
+					action = config.OverlapPolicySkip
+				}
+				next, queued = ts, true
+			}
+
+			l.Warn("Previous monitoring pass is still running",
+				zap.String("action", action),
+				zap.Int64("ts", ts.Unix()),
+			)
+			metrics.MonitorLateTicksCount.Add(ctx, 1, otelapi.WithAttributes(
+				attribute.KeyValue{Key: "action", Value: attribute.StringValue(action)},
+			))
+
+		case <-done:
+			cancel()
+			running = false
+			if queued {
+				queued = false
+				start(next)
+			}
+		}
+	}
+}
+
 func (s *Server) monitor(ctx context.Context, ts time.Time) {
 	l := logutils.LoggerFromContext(ctx).With(
 		zap.Int64("ts", ts.Unix()),
 	)
 	ctx = logutils.ContextWithLogger(ctx, l)
 
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
 	l.Debug("Running new monitoring pass...")
 
+	start := time.Now()
+	defer func() {
+		metrics.MonitorPassDuration.Record(ctx, time.Since(start).Seconds())
+	}()
+
 	var (
 		status = make(map[string]*types.BuilderStatus, len(s.builders))
 		mx     sync.Mutex
 		wg     sync.WaitGroup
 	)
 
-	for name, rpc := range s.builders {
+	for name := range s.builders {
 		wg.Add(1)
 
 		go func() {
 			defer wg.Done()
 
-			s := s.getStatus(ctx, rpc)
+			s := s.getStatus(ctx, name)
 			mx.Lock()
 			status[name] = s
 			mx.Unlock()
@@ -42,63 +119,273 @@ func (s *Server) monitor(ctx context.Context, ts time.Time) {
 
 	wg.Wait()
 
-	s.process(ctx, status)
+	if err := ctx.Err(); err != nil {
+		l.Warn("Monitoring pass was cancelled",
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := s.process(ctx, ts, status); err != nil {
+		l.Warn("Monitoring pass was cancelled",
+			zap.Error(err),
+		)
+		return
+	}
+
+	metrics.MonitorLastPassTimestamp.Record(ctx, time.Now().Unix())
 }
 
-func (s *Server) process(ctx context.Context, status map[string]*types.BuilderStatus) {
-	s.analysePeers(ctx, status)
-	s.analyseTxpool(ctx, status)
+// process analyses the status of the builders and reports the findings.  If
+// the pass gets cancelled midway, the results of the analysis are incomplete
+// (the rpc calls fail), and therefore nothing is reported.
+func (s *Server) process(ctx context.Context, ts time.Time, status map[string]*types.BuilderStatus) error {
+	// the txpools of skewed builders are excluded from everything downstream
+	status, skewed := s.excludeSkewed(status)
+
+	txpoolFindings, divergence := s.analyseTxpool(ctx, status)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	headFindings := s.analyseHead(ctx, status)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	reorgFindings := s.analyseReorgs(ctx, status)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	peers := s.analysePeers(ctx, status)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	findings := slices.Concat(
+		headFindings,
+		reorgFindings,
+		skewed,
+		txpoolFindings,
+	)
+
+	s.report(ctx, ts, status, findings)
+
+	s.last.Store(s.buildReport(ts, status, peers, divergence, findings))
+
+	return nil
 }
 
-func (s *Server) getStatus(ctx context.Context, builder *ethclient.Client) *types.BuilderStatus {
-	l := logutils.LoggerFromContext(ctx)
+func (s *Server) getStatus(ctx context.Context, name string) *types.BuilderStatus {
+	l := logutils.LoggerFromContext(ctx).With(
+		zap.String("builder", name),
+	)
 
+	b := s.builder(name)
 	res := &types.BuilderStatus{}
 	errs := make([]error, 0)
 
-	if peers, err := s.getPeers(ctx, builder); err == nil {
-		res.Peers = peers
-	} else {
-		errs = append(errs, err)
-		l.Error("Failed to get builder's peers",
-			zap.Error(err),
-		)
+	if s.isEnabled(name, config.CheckHead) {
+		if head, source, err := s.getHead(ctx, name); err == nil {
+			res.Head, res.HeadSource = head, source
+			res.Block = uint64(head.Number)
+			s.markUpdated(ctx, name, config.CheckHead)
+		} else {
+			errs = append(errs, err)
+			l.Error("Failed to get builder's head",
+				zap.Error(err),
+			)
+		}
 	}
 
-	if txpool, err := s.getTxpool(ctx, builder); err == nil {
-		res.Txpool = txpool
-	} else {
-		errs = append(errs, err)
-		l.Error("Failed to get builder's txpool",
-			zap.Error(err),
-		)
+	if s.isEnabled(name, config.CheckPeers) {
+		if peers, err := s.getPeers(ctx, name); err == nil {
+			res.Peers = peers
+			s.markUpdated(ctx, name, config.CheckPeers)
+		} else {
+			errs = append(errs, err)
+			l.Error("Failed to get builder's peers",
+				zap.Error(err),
+			)
+		}
+	}
+
+	txpoolRequested := time.Now()
+	if s.isEnabled(name, config.CheckTxpool) {
+		if txpool, err := s.getTxpool(ctx, name); err == nil {
+			res.Txpool = txpool
+			s.markUpdated(ctx, name, config.CheckTxpool)
+		} else {
+			errs = append(errs, err)
+			l.Error("Failed to get builder's txpool",
+				zap.Error(err),
+			)
+		}
+	}
+
+	announced := int64(0)
+	if b.stream != nil {
+		// the txpool snapshot is authoritative about the txs announced before
+		// it was requested, so only the later ones are kept (without the
+		// snapshot, the ones announced since the previous pass are kept)
+		cutoff := time.Now().Add(-2 * s.cfg.Monitor.Interval)
+		if res.Txpool != nil {
+			cutoff = txpoolRequested
+		}
+		res.Stream, announced = b.stream.snapshot(cutoff)
 	}
 
 	res.Err = utils.FlattenErrors(errs)
+	res.Down = slices.ContainsFunc(errs, func(err error) bool {
+		// the subscription being down does not mean that the rpc calls fail
+		return !errors.Is(err, errStreamDisconnected) && isEndpointFailure(err)
+	})
+
+	up := int64(1)
+	if res.Down {
+		up = 0
+	}
+	metrics.BuilderUp.Record(ctx, up, otelapi.WithAttributes(
+		s.builderAttributes(name)...,
+	))
+
+	if res.Stream != nil {
+		connected := int64(0)
+		if res.Stream.Connected {
+			connected = 1
+		}
+		metrics.BuilderStreamConnected.Record(ctx, connected, otelapi.WithAttributes(
+			s.builderAttributes(name)...,
+		))
+		metrics.BuilderStreamAnnouncedTxCount.Add(ctx, announced, otelapi.WithAttributes(
+			s.builderAttributes(name)...,
+		))
+	}
+
+	active := int(b.active.Load())
+	for idx, e := range b.endpoints {
+		endpoint := endpointAttribute(e)
+
+		isActive := int64(0)
+		if idx == active {
+			isActive = 1
+		}
+		metrics.BuilderEndpointActive.Record(ctx, isActive, otelapi.WithAttributes(
+			s.builderAttributes(name, endpoint)...,
+		))
+
+		if expiry := e.certExpiry.Load(); expiry != 0 {
+			metrics.TLSCertificateExpiry.Record(ctx, expiry, otelapi.WithAttributes(
+				s.builderAttributes(name, endpoint)...,
+			))
+		}
+	}
 
 	return res
 }
 
-func (s *Server) getPeers(ctx context.Context, builder *ethclient.Client) (*jrpc.AdminPeers, error) {
-	ctx, cancel := context.WithTimeout(ctx, s.cfg.Monitor.Timeout)
-	defer cancel()
+func (s *Server) markUpdated(ctx context.Context, builder, section string) {
+	metrics.BuilderLastUpdated.Record(ctx, time.Now().Unix(), otelapi.WithAttributes(s.builderAttributes(builder,
+		attribute.KeyValue{Key: "section", Value: attribute.StringValue(section)},
+	)...))
+}
+
+// getHead returns the head of the builder together with the endpoint it was
+// taken from.  The builders with websocket subscriptions report their heads
+// only via the stream, so that the consecutive heads come from the same node
+// (otherwise the differences between the nodes would look like reorgs).
+func (s *Server) getHead(ctx context.Context, builder string) (*jrpc.EthBlock, string, error) {
+	if b := s.builder(builder); b.stream != nil {
+		head := b.stream.latest()
+		if head == nil {
+			return nil, "", errStreamDisconnected
+		}
+		return head, b.stream.label, nil
+	}
+
+	var (
+		attrs  = s.builderAttributes(builder)
+		res    = &jrpc.EthBlock{}
+		source string
+	)
+	if err := s.failover(ctx, builder, func(ctx context.Context, e *endpoint) error {
+		source = e.label
+		return callEndpoint(ctx, attrs, e, res, "eth_getBlockByNumber", "latest", false)
+	}); err != nil {
+		return nil, "", err
+	}
+
+	return res, source, nil
+}
+
+func (s *Server) getBlockByHash(ctx context.Context, builder string, hash string) (*jrpc.EthBlock, error) {
+	var res *jrpc.EthBlock
+	if err := s.call(ctx, builder, &res, "eth_getBlockByHash", hash, false); err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, fmt.Errorf("block not found: %s", hash)
+	}
 
+	return res, nil
+}
+
+func (s *Server) getPeers(ctx context.Context, builder string) (*jrpc.AdminPeers, error) {
 	res := &jrpc.AdminPeers{}
-	if err := builder.Client().CallContext(ctx, res, "admin_peers"); err != nil {
+	if err := s.call(ctx, builder, res, "admin_peers"); err != nil {
 		return nil, err
 	}
 
 	return res, nil
 }
 
-func (s *Server) getTxpool(ctx context.Context, builder *ethclient.Client) (*jrpc.TxpoolContent, error) {
-	ctx, cancel := context.WithTimeout(ctx, s.cfg.Monitor.Timeout)
-	defer cancel()
+// getTxpool returns the txpool of the builder: either its view maintained via
+// websocket subscriptions (unless the new snapshot is due), or the snapshot
+// (which the view is then reset to).
+func (s *Server) getTxpool(ctx context.Context, builder string) (*jrpc.TxpoolContent, error) {
+	if res := s.getTxpoolView(ctx, builder); res != nil {
+		return res, nil
+	}
 
+	requested := time.Now()
 	res := &jrpc.TxpoolContent{}
-	if err := builder.Client().CallContext(ctx, res, "txpool_content"); err != nil {
+	if err := s.call(ctx, builder, res, "txpool_content"); err != nil {
 		return nil, err
 	}
+	if b := s.builder(builder); b.stream != nil {
+		b.stream.reset(res, requested)
+	}
 
 	return res, nil
 }
+
+// getTxpoolView returns the view of the builder's txpool maintained via
+// websocket subscriptions, having dropped the txs included in the new blocks
+// from it.  It returns nil if there is no view, if the new snapshot is due, or
+// if the new blocks could not be fetched.
+func (s *Server) getTxpoolView(ctx context.Context, builder string) *jrpc.TxpoolContent {
+	b := s.builder(builder)
+	if b.stream == nil || s.cfg.Monitor.TxpoolSnapshotInterval == 0 {
+		return nil
+	}
+
+	blocks, ok := b.stream.pendingBlocks(time.Now().Add(-s.cfg.Monitor.TxpoolSnapshotInterval))
+	if !ok {
+		return nil
+	}
+	for _, hash := range blocks {
+		var block *jrpc.EthBlockTxs
+		if err := s.call(ctx, builder, &block, "eth_getBlockByHash", hash, true); err != nil || block == nil {
+			logutils.LoggerFromContext(ctx).Debug("Failed to get new block, falling back to txpool snapshot",
+				zap.Error(err),
+				zap.String("builder", builder),
+				zap.String("hash", hash),
+			)
+			return nil
+		}
+		b.stream.include(hash, block.Transactions)
+	}
+
+	return b.stream.view()
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/jrpc"
+)
+
+// testHead serves the head block via json-rpc (and nothing else).
+type testHead struct{}
+
+func (testHead) GetBlockByNumber(_ string, _ bool) *jrpc.EthBlock {
+	return block(10, "a10", "a9")
+}
+
+func TestGetStatusDown(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		checks   []string
+		stream   bool
+		stopped  bool
+		wantErr  bool
+		wantDown bool
+	}{
+		{
+			name:   "all calls succeed",
+			checks: []string{config.CheckHead},
+		},
+		{
+			name:    "namespaces are not exposed",
+			checks:  []string{config.CheckHead, config.CheckPeers, config.CheckTxpool},
+			wantErr: true,
+		},
+		{
+			name:    "subscription is down",
+			checks:  []string{config.CheckHead},
+			stream:  true,
+			wantErr: true,
+		},
+		{
+			name:     "builder is stopped",
+			checks:   []string{config.CheckHead},
+			stopped:  true,
+			wantErr:  true,
+			wantDown: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, "b0")
+			httpSrv := serveTestBuilder(t, s, &config.Builder{Name: "b0", Checks: tc.checks}, map[string]any{"eth": testHead{}})
+			if tc.stream {
+				s.builders["b0"].stream = newStream("ws://builder", nil)
+			}
+
+			if tc.stopped {
+				httpSrv.Close()
+			}
+
+			status := s.getStatus(context.Background(), "b0")
+			if gotErr := status.Err != nil; gotErr != tc.wantErr {
+				t.Errorf("getStatus() error = %v, want error: %t", status.Err, tc.wantErr)
+			}
+			if status.Down != tc.wantDown {
+				t.Errorf("getStatus() down = %t, want %t (error: %v)", status.Down, tc.wantDown, status.Err)
+			}
+		})
+	}
+}
+
+// testTxpool serves the txpool snapshots and the blocks with full txs via
+// json-rpc, counting the snapshots.
+type testTxpool struct {
+	content   *jrpc.TxpoolContent
+	blocks    map[string]*jrpc.EthBlockTxs
+	snapshots int
+}
+
+func (p *testTxpool) Content() *jrpc.TxpoolContent {
+	p.snapshots++
+	return p.content
+}
+
+func (p *testTxpool) GetBlockByHash(hash string, _ bool) *jrpc.EthBlockTxs {
+	return p.blocks[hash]
+}
+
+func TestGetTxpoolView(t *testing.T) {
+	node := &testTxpool{
+		content: &jrpc.TxpoolContent{
+			Pending: map[string]map[string]*jrpc.TxpoolContent_Tx{
+				testSender: {"1": streamTx(testSender, "0x1", "p1")},
+			},
+		},
+		blocks: map[string]*jrpc.EthBlockTxs{
+			"b10": {Hash: "b10", Transactions: []*jrpc.TxpoolContent_Tx{streamTx(testSender, "0x1", "p1")}},
+		},
+	}
+	s := newTestServer(t, "b0")
+	s.cfg.Monitor.TxpoolSnapshotInterval = time.Minute
+	serveTestBuilder(t, s, &config.Builder{Name: "b0"}, map[string]any{"txpool": node, "eth": node})
+	b := s.builders["b0"]
+	b.stream = newStream("ws://builder", nil)
+	b.stream.setConnected(true)
+
+	for idx, step := range []struct {
+		run           func()
+		wantPending   []string
+		wantSnapshots int
+	}{
+		{
+			wantPending:   []string{"p1"},
+			wantSnapshots: 1,
+		},
+		{
+			run:           func() { b.stream.onTx(streamTx(testSender, "0x2", "p2"), time.Now()) },
+			wantPending:   []string{"p1", "p2"},
+			wantSnapshots: 1,
+		},
+		{
+			run:           func() { b.stream.onHead(block(10, "b10", "b9"), time.Now()) },
+			wantPending:   []string{"p2"},
+			wantSnapshots: 1,
+		},
+		{
+			run:           func() { b.stream.onHead(block(11, "unknown", "b10"), time.Now()) },
+			wantPending:   []string{"p1"}, // fell back to the snapshot
+			wantSnapshots: 2,
+		},
+		{
+			run: func() {
+				b.stream.setConnected(false)
+				b.stream.setConnected(true)
+			},
+			wantPending:   []string{"p1"},
+			wantSnapshots: 3,
+		},
+	} {
+		if step.run != nil {
+			step.run()
+		}
+		txpool, err := s.getTxpool(context.Background(), "b0")
+		if err != nil {
+			t.Fatalf("step %d: %v", idx, err)
+		}
+		if got := streamHashes(txpool.Pending); !slices.Equal(got, step.wantPending) {
+			t.Errorf("step %d: pending = %v, want %v", idx, got, step.wantPending)
+		}
+		if node.snapshots != step.wantSnapshots {
+			t.Errorf("step %d: snapshots = %d, want %d", idx, node.snapshots, step.wantSnapshots)
+		}
+	}
+}
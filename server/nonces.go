@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/utils"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// nonceCache keeps the confirmed nonces of the accounts as of the specific
+// block, so that they are not re-queried until builder's head moves on.
+type nonceCache struct {
+	block  string
+	nonces map[string]uint64
+}
+
+type nonceBatch struct {
+	addresses []string
+	elems     []rpc.BatchElem
+}
+
+// getNonces returns the confirmed nonces of the given addresses as of the
+// builder's head.  The lookups are sent in batches by a bounded pool of
+// workers, and the addresses which nonce could not be fetched are omitted.
+func (s *Server) getNonces(ctx context.Context, builder string, head *jrpc.EthBlock, addresses []string) map[string]uint64 {
+	l := logutils.LoggerFromContext(ctx)
+
+	block := "latest"
+	cache := &nonceCache{}
+	if head != nil {
+		block = hexutil.EncodeUint64(uint64(head.Number))
+		cache = s.nonces[builder]
+	}
+	if cache.nonces == nil || head == nil || cache.block != head.Hash {
+		cache.nonces = make(map[string]uint64, len(addresses))
+		if head != nil {
+			cache.block = head.Hash
+		}
+	}
+
+	batches := make([]*nonceBatch, 0)
+	{ // prepare the batches
+		var batch *nonceBatch
+		for _, addr := range addresses {
+			if _, known := cache.nonces[addr]; known {
+				continue
+			}
+			addrEth, err := utils.ParseAddress(addr)
+			if err != nil {
+				l.Warn("Failed to parse a tx from address",
+					zap.Error(err),
+					zap.String("addr", addr),
+					zap.String("builder", builder),
+				)
+				continue
+			}
+			if batch == nil || len(batch.elems) == s.cfg.Monitor.NonceBatchSize {
+				batch = &nonceBatch{
+					addresses: make([]string, 0, s.cfg.Monitor.NonceBatchSize),
+					elems:     make([]rpc.BatchElem, 0, s.cfg.Monitor.NonceBatchSize),
+				}
+				batches = append(batches, batch)
+			}
+			batch.addresses = append(batch.addresses, addr)
+			batch.elems = append(batch.elems, rpc.BatchElem{
+				Method: "eth_getTransactionCount",
+				Args:   []interface{}{addrEth, block},
+				Result: new(hexutil.Uint64),
+			})
+		}
+	}
+
+	if len(batches) == 0 {
+		return cache.nonces
+	}
+
+	var (
+		mx    sync.Mutex
+		wg    sync.WaitGroup
+		queue = make(chan *nonceBatch)
+	)
+
+	for range min(s.cfg.Monitor.NonceWorkers, len(batches)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for batch := range queue {
+				err := s.batchCall(ctx, builder, batch.elems)
+				if err != nil {
+					l.Warn("Failed to get confirmed nonces",
+						zap.Error(err),
+						zap.String("builder", builder),
+						zap.Int("count", len(batch.elems)),
+					)
+					continue
+				}
+
+				mx.Lock()
+				for idx, elem := range batch.elems {
+					if elem.Error != nil {
+						l.Warn("Failed to get confirmed nonce",
+							zap.Error(elem.Error),
+							zap.String("addr", batch.addresses[idx]),
+							zap.String("builder", builder),
+						)
+						continue
+					}
+					cache.nonces[batch.addresses[idx]] = uint64(*elem.Result.(*hexutil.Uint64))
+				}
+				mx.Unlock()
+			}
+		}()
+	}
+
+	for _, batch := range batches {
+		queue <- batch
+	}
+	close(queue)
+
+	wg.Wait()
+
+	return cache.nonces
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ConfigLoader produces the up-to-date configuration on reload.
+type ConfigLoader func() (*config.Config, error)
+
+var (
+	errReloadNotSupported = errors.New("configuration reload is not supported")
+)
+
+// reload re-reads the configuration and applies the changes of the builders
+// and peers lists.  New (or reconfigured) builders are dialled, removed ones
+// are closed and their metric series are dropped.  The state is swapped between monitoring
+// passes, and other configuration changes require restart.
+func (s *Server) reload(ctx context.Context) error {
+	l := logutils.LoggerFromContext(ctx)
+
+	if s.loader == nil {
+		return errReloadNotSupported
+	}
+
+	s.reloadMx.Lock()
+	defer s.reloadMx.Unlock()
+
+	cfg, err := s.loader()
+	if err != nil {
+		return err
+	}
+
+	peers, err := parsePeers(cfg.Monitor.Peers)
+	if err != nil {
+		return err
+	}
+
+	// only reload modifies the builders map, so it can be read without
+	// waiting for the monitoring pass (that we don't want to hold while
+	// dialling the new builders)
+	s.pollMx.RLock()
+	current := maps.Clone(s.builders)
+	s.pollMx.RUnlock()
+
+	var (
+		builders = make(map[string]*builder, len(cfg.Monitor.Builders))
+		added    = make([]*builder, 0)
+	)
+
+	for _, b := range cfg.Monitor.Builders {
+		name := b.Name
+		prev, known := current[name]
+		if known && reflect.DeepEqual(prev.cfg, b) {
+			builders[name] = prev
+			continue
+		}
+		client, err := dialBuilder(ctx, b, s.cfg.Monitor.Timeout)
+		if err != nil {
+			for _, b := range added {
+				b.close()
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		added = append(added, client)
+		builders[name] = client
+		if known {
+			l.Info("Builder updated",
+				zap.String("builder", name),
+			)
+		} else {
+			l.Info("Builder added",
+				zap.String("builder", name),
+			)
+		}
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var (
+		heads  = make(map[string]*headHistory, len(builders))
+		nonces = make(map[string]*nonceCache, len(builders))
+	)
+	for name, b := range builders {
+		if b == s.builders[name] {
+			heads[name] = s.heads[name]
+			nonces[name] = s.nonces[name]
+		} else {
+			heads[name] = newHeadHistory(headHistorySize)
+			nonces[name] = &nonceCache{}
+		}
+	}
+	if s.reference != nil {
+		nonces[s.referenceName] = s.nonces[s.referenceName]
+	}
+
+	// the attributes of the builders before the reload (when the set of label
+	// names changes, all of them get new attributes)
+	attributes := make(map[string][]attribute.KeyValue, len(s.builders)+1)
+	for name := range s.builders {
+		attributes[name] = s.builderAttributes(name)
+	}
+	if s.reference != nil {
+		attributes[s.referenceName] = s.builderAttributes(s.referenceName)
+	}
+
+	for name, prev := range s.builders {
+		if builders[name] == prev {
+			continue
+		}
+		prev.close()
+		if _, present := builders[name]; !present {
+			s.forgetBuilder(name)
+			l.Info("Builder removed",
+				zap.String("builder", name),
+			)
+		}
+	}
+
+	s.cfg.Monitor.Builders = cfg.Monitor.Builders
+	s.cfg.Monitor.Peers = cfg.Monitor.Peers
+
+	previous := s.builders
+
+	s.pollMx.Lock()
+	s.builders = builders
+	s.labelKeys = labelKeys(builders, s.reference)
+	s.pollMx.Unlock()
+	s.heads = heads
+	s.nonces = nonces
+	s.peers = peers
+
+	// counters and histograms can not forget their series, so the ones with
+	// outdated attributes are retired (and revived if they become current
+	// again, e.g. when the removed builder is added back)
+	for name, prev := range attributes {
+		b := s.builder(name)
+		if b == nil {
+			metrics.Retire(prev...)
+			continue
+		}
+
+		next := s.builderAttributes(name)
+		relabelled := !slices.Equal(prev, next)
+		if relabelled {
+			metrics.Forget(prev[0])
+			metrics.Retire(prev...)
+			metrics.Revive(next...)
+		}
+		old := previous[name]
+		if old == nil || old == b {
+			continue
+		}
+		if !relabelled {
+			metrics.Forget(prev[0])
+		}
+		for _, e := range old.endpoints { // the labels are unique per builder
+			if !slices.ContainsFunc(b.endpoints, func(_e *endpoint) bool { return _e.label == e.label }) {
+				metrics.Retire(append(slices.Clone(prev), endpointAttribute(e))...)
+			}
+		}
+	}
+	for _, b := range added {
+		attrs := s.builderAttributes(b.cfg.Name)
+		metrics.Revive(attrs...)
+		for _, e := range b.endpoints {
+			metrics.Revive(append(slices.Clone(attrs), endpointAttribute(e))...)
+		}
+		b.start(s.logger)
+	}
+
+	l.Info("Configuration reloaded",
+		zap.Int("builders", len(builders)),
+		zap.Int("peers", len(peers)),
+	)
+
+	return nil
+}
+
+// forgetBuilder drops the metric series and the state of the removed
+// builder.
+func (s *Server) forgetBuilder(name string) {
+	metrics.Forget(attribute.KeyValue{Key: "builder", Value: attribute.StringValue(name)})
+	metrics.Forget(attribute.KeyValue{Key: "other", Value: attribute.StringValue(name)})
+
+	for series := range s.findingsSeries {
+		if series.builder == name {
+			delete(s.findingsSeries, series)
+		}
+	}
+	maps.DeleteFunc(s.findings, func(_ string, f *types.Finding) bool {
+		return f.Builder == name || f.Other == name
+	})
+	s.txs.forget(name)
+}
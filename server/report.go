@@ -0,0 +1,99 @@
+package server
+
+import (
+	"time"
+
+	"github.com/flashbots/bmonitor/types"
+)
+
+// buildReport assembles the outcome of the monitoring pass for the api.
+func (s *Server) buildReport(
+	ts time.Time,
+	status map[string]*types.BuilderStatus,
+	peers map[string]*types.PeersReport,
+	divergence map[string]map[string]*types.DivergenceReport,
+	findings []*types.Finding,
+) *types.Report {
+	report := &types.Report{
+		Timestamp:  ts,
+		Builders:   make(map[string]*types.BuilderReport, len(status)),
+		Divergence: divergence,
+		Findings:   findings,
+	}
+
+	for builder, sts := range status {
+		res := &types.BuilderReport{
+			Reachable: !sts.Down,
+			Labels:    s.labels(builder),
+			Peers:     peers[builder],
+		}
+		if sts.Err != nil {
+			res.Error = sts.Err.Error()
+		}
+
+		if sts.Head != nil {
+			res.Head = &types.HeadReport{
+				Number:     uint64(sts.Head.Number),
+				Hash:       sts.Head.Hash,
+				ParentHash: sts.Head.ParentHash,
+				Timestamp:  uint64(sts.Head.Timestamp),
+			}
+		}
+
+		if sts.Stream != nil {
+			res.Stream = &types.StreamReport{
+				Connected:    sts.Stream.Connected,
+				AnnouncedTxs: len(sts.Stream.Announced),
+			}
+		}
+
+		if sts.Txpool != nil {
+			res.Txpool = &types.TxpoolReport{
+				NonceGaps:  make([]*types.NonceGapReport, 0),
+				MissingTxs: make([]*types.MissingTxReport, 0),
+			}
+			for _, nonces := range sts.Txpool.Pending {
+				res.Txpool.Pending += len(nonces)
+			}
+			for _, nonces := range sts.Txpool.Queued {
+				res.Txpool.Queued += len(nonces)
+			}
+		}
+
+		report.Builders[builder] = res
+	}
+
+	for _, f := range findings {
+		res, known := report.Builders[f.Builder]
+		if !known || res.Txpool == nil {
+			continue
+		}
+
+		switch f.Kind {
+		case types.FindingNonceGap:
+			res.Txpool.NonceGaps = append(res.Txpool.NonceGaps, &types.NonceGapReport{
+				Address: f.Address,
+				Start:   f.Nonces.Start,
+				End:     f.Nonces.End,
+			})
+
+		case types.FindingMissingTx:
+			missing := &types.MissingTxReport{
+				Address: f.Address,
+				Nonce:   f.Nonces.Start,
+			}
+			if len(f.TxHashes) > 0 {
+				missing.Hash = f.TxHashes[0]
+			}
+			res.Txpool.MissingTxs = append(res.Txpool.MissingTxs, missing)
+
+		case types.FindingIncludedTx:
+			res.Txpool.IncludedTxs++
+
+		case types.FindingReplacedTx:
+			res.Txpool.ReplacedTxs++
+		}
+	}
+
+	return report
+}
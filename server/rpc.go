@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/utils"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+)
+
+// call invokes json-rpc method on the builder (failing over to its other
+// endpoints if needed) and records the latency, the outcome, and the size of
+// the response.
+func (s *Server) call(ctx context.Context, builder string, result interface{}, method string, args ...interface{}) error {
+	attrs := s.builderAttributes(builder)
+	return s.failover(ctx, builder, func(ctx context.Context, e *endpoint) error {
+		return callEndpoint(ctx, attrs, e, result, method, args...)
+	})
+}
+
+// callEndpoint invokes json-rpc method on the specific endpoint of the
+// builder identified by its metric attributes (see call).
+func callEndpoint(ctx context.Context, builder []attribute.KeyValue, e *endpoint, result interface{}, method string, args ...interface{}) error {
+	start := time.Now()
+
+	raw := json.RawMessage{}
+	err := e.client.CallContext(ctx, &raw, method, args...)
+	if err == nil {
+		err = json.Unmarshal(raw, result)
+	}
+
+	duration := time.Since(start)
+
+	attrs := append(slices.Clone(builder),
+		endpointAttribute(e),
+		attribute.KeyValue{Key: "method", Value: attribute.StringValue(method)},
+	)
+
+	metrics.RPCCallDuration.Record(ctx, duration.Seconds(), otelapi.WithAttributes(attrs...))
+
+	if err != nil {
+		metrics.RPCCallsCount.Add(ctx, 1, otelapi.WithAttributes(
+			append(attrs, attribute.KeyValue{Key: "status", Value: attribute.StringValue("failure")})...,
+		))
+		metrics.RPCErrorsCount.Add(ctx, 1, otelapi.WithAttributes(
+			append(attrs, attribute.KeyValue{Key: "reason", Value: attribute.StringValue(utils.ClassifyRPCError(err))})...,
+		))
+		return err
+	}
+
+	metrics.RPCCallsCount.Add(ctx, 1, otelapi.WithAttributes(
+		append(attrs, attribute.KeyValue{Key: "status", Value: attribute.StringValue("success")})...,
+	))
+	metrics.RPCResponseSize.Record(ctx, int64(len(raw)), otelapi.WithAttributes(attrs...))
+
+	return nil
+}
+
+// batchCall sends the batch of json-rpc requests to the builder (failing over
+// to its other endpoints if needed) and records the latency and the outcome
+// of the batch as a whole (as well as the errors of its individual elements).
+func (s *Server) batchCall(ctx context.Context, builder string, batch []rpc.BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return s.failover(ctx, builder, func(ctx context.Context, e *endpoint) error {
+		for idx := range batch {
+			batch[idx].Error = nil
+		}
+
+		start := time.Now()
+
+		err := e.client.BatchCallContext(ctx, batch)
+
+		duration := time.Since(start)
+
+		attrs := s.builderAttributes(builder,
+			endpointAttribute(e),
+			attribute.KeyValue{Key: "method", Value: attribute.StringValue("batch:" + batch[0].Method)},
+		)
+
+		metrics.RPCCallDuration.Record(ctx, duration.Seconds(), otelapi.WithAttributes(attrs...))
+
+		if err != nil {
+			metrics.RPCCallsCount.Add(ctx, 1, otelapi.WithAttributes(
+				append(attrs, attribute.KeyValue{Key: "status", Value: attribute.StringValue("failure")})...,
+			))
+			metrics.RPCErrorsCount.Add(ctx, 1, otelapi.WithAttributes(
+				append(attrs, attribute.KeyValue{Key: "reason", Value: attribute.StringValue(utils.ClassifyRPCError(err))})...,
+			))
+			return err
+		}
+
+		metrics.RPCCallsCount.Add(ctx, 1, otelapi.WithAttributes(
+			append(attrs, attribute.KeyValue{Key: "status", Value: attribute.StringValue("success")})...,
+		))
+
+		for _, elem := range batch {
+			if elem.Error == nil {
+				continue
+			}
+			metrics.RPCErrorsCount.Add(ctx, 1, otelapi.WithAttributes(s.builderAttributes(builder,
+				endpointAttribute(e),
+				attribute.KeyValue{Key: "method", Value: attribute.StringValue(elem.Method)},
+				attribute.KeyValue{Key: "reason", Value: attribute.StringValue(utils.ClassifyRPCError(elem.Error))},
+			)...))
+		}
+
+		return nil
+	})
+}
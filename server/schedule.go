@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/logutils"
+	"github.com/flashbots/bmonitor/types"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ticks returns the channel that triggers monitoring passes according to the
+// configured schedule.
+func (s *Server) ticks(ctx context.Context) <-chan time.Time {
+	if s.cfg.Monitor.Schedule != config.ScheduleBlocks {
+		return s.ticker.C
+	}
+
+	res := make(chan time.Time)
+
+	go func() {
+		l := logutils.LoggerFromContext(ctx)
+
+		var (
+			last   uint64
+			lastTs time.Time
+		)
+
+		for range s.ticker.C {
+			number, reached := s.pollHeads(ctx, last)
+			switch {
+			case reached:
+				l.Debug("Quorum of builders reported new head",
+					zap.Uint64("number", number),
+				)
+				last = number
+				time.Sleep(s.cfg.Monitor.BlockDelay)
+			case time.Since(lastTs) < s.cfg.Monitor.Interval:
+				continue
+			}
+			lastTs = time.Now()
+			select {
+			case res <- lastTs:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return res
+}
+
+// pollHeads checks whether the quorum of builders has reported the head above
+// the given one, and returns the highest reported head.  The heads of the
+// builders with websocket subscriptions are taken from them, and the rest
+// are polled.
+//
+// It does not wait for the monitoring pass to complete (so that overlapping
+// passes are detected), and therefore works with the snapshot of the builders
+// and of their metric attributes.
+func (s *Server) pollHeads(ctx context.Context, above uint64) (uint64, bool) {
+	s.pollMx.RLock()
+	builders := maps.Clone(s.builders)
+	attrs := make(map[string][]attribute.KeyValue, len(builders))
+	for name := range builders {
+		attrs[name] = s.builderAttributes(name)
+	}
+	s.pollMx.RUnlock()
+
+	var (
+		heads = make(map[string]uint64, len(builders))
+		mx    sync.Mutex
+		wg    sync.WaitGroup
+	)
+
+	for name, b := range builders {
+		if !b.cfg.IsEnabled(config.CheckHead) {
+			continue
+		}
+
+		if b.stream != nil {
+			// the heads are taken from the stream only (see getHead)
+			if head := b.stream.latest(); head != nil {
+				heads[name] = uint64(head.Number)
+			}
+			continue
+		}
+
+		timeout := s.cfg.Monitor.Timeout
+		if b.cfg.Timeout != 0 {
+			timeout = b.cfg.Timeout
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			head := &jrpc.EthBlock{}
+			err := b.failover(ctx, timeout, func(ctx context.Context, e *endpoint) error {
+				return callEndpoint(ctx, attrs[name], e, head, "eth_getBlockByNumber", "latest", false)
+			})
+			if err != nil {
+				return
+			}
+			mx.Lock()
+			heads[name] = uint64(head.Number)
+			mx.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	quorum := s.cfg.Monitor.BlockQuorum
+	if quorum == 0 {
+		quorum = len(heads)
+	}
+
+	var (
+		count   = 0
+		highest = above
+	)
+	for _, number := range heads {
+		if number > above {
+			count++
+			highest = max(highest, number)
+		}
+	}
+
+	return highest, count > 0 && count >= quorum
+}
+
+// excludeSkewed returns the status without the txpools of the builders whose
+// snapshots were taken at a block different from the one most builders were
+// at (with blocks schedule only), together with the findings about them.
+func (s *Server) excludeSkewed(status map[string]*types.BuilderStatus) (map[string]*types.BuilderStatus, []*types.Finding) {
+	if s.cfg.Monitor.Schedule != config.ScheduleBlocks {
+		return status, make([]*types.Finding, 0)
+	}
+
+	counts := make(map[uint64]int)
+	for _, sts := range status {
+		if sts.Txpool != nil && sts.Block != 0 {
+			counts[sts.Block]++
+		}
+	}
+
+	var block uint64
+	for number, count := range counts {
+		if count > counts[block] || (count == counts[block] && number > block) {
+			block = number
+		}
+	}
+
+	var (
+		res      = make(map[string]*types.BuilderStatus, len(status))
+		findings = make([]*types.Finding, 0)
+	)
+	for builder, sts := range status {
+		if sts.Txpool == nil || sts.Block == 0 || sts.Block == block {
+			res[builder] = sts
+			continue
+		}
+
+		skewed := *sts
+		skewed.Txpool = nil
+		res[builder] = &skewed
+
+		findings = append(findings, &types.Finding{
+			Kind:        types.FindingSkewedSnapshot,
+			Severity:    types.SeverityInfo,
+			Message:     "Builder's snapshot was taken at different block, its txpool is not analysed",
+			Builder:     builder,
+			BlockNumber: sts.Block,
+		})
+	}
+
+	return res, findings
+}
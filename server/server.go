@@ -8,8 +8,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,80 +18,104 @@ import (
 	"github.com/flashbots/bmonitor/httplogger"
 	"github.com/flashbots/bmonitor/logutils"
 	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
 	"github.com/flashbots/bmonitor/utils"
 
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
-
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type Server struct {
 	cfg *config.Config
 
 	failure chan error
+	loader  ConfigLoader
 
 	logger *zap.Logger
 	server *http.Server
 
-	builders map[string]*ethclient.Client
-	peers    map[string]string
-	ticker   *time.Ticker
+	reloadMx sync.Mutex   // serialises the reloads
+	pollMx   sync.RWMutex // guards the swap of the builders map and of label keys (so that heads can be polled while monitoring pass is running)
+
+	mx sync.Mutex // guards the state below while monitoring pass or reload is running
+
+	builders  map[string]*builder
+	heads     map[string]*headHistory
+	labelKeys []string
+	nonces    map[string]*nonceCache
+	peers     map[string]string
+	ticker    *time.Ticker
+	txs       *txTracker
+
+	reference     *builder
+	referenceName string
+
+	findings       map[string]*types.Finding // finding id -> finding of the previous pass
+	findingsSeries map[findingsSeries]struct{}
+	last           atomic.Pointer[types.Report]
 }
 
-func New(cfg *config.Config) (*Server, error) {
-	builders := make(map[string]*ethclient.Client, len(cfg.Monitor.Builders))
+func New(cfg *config.Config, loader ConfigLoader) (*Server, error) {
+	builders := make(map[string]*builder, len(cfg.Monitor.Builders))
+	heads := make(map[string]*headHistory, len(cfg.Monitor.Builders))
+	nonces := make(map[string]*nonceCache, len(cfg.Monitor.Builders))
 	for _, b := range cfg.Monitor.Builders {
-		parts := strings.Split(b, "=")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid builder: %s", b)
-		}
-		name := strings.TrimSpace(parts[0])
-		rpc, err := ethclient.Dial(strings.TrimSpace(parts[1]))
+		name := b.Name
+		client, err := dialBuilder(context.Background(), b, cfg.Monitor.Timeout)
 		if err != nil {
 			return nil, err
 		}
-		builders[name] = rpc
+		builders[name] = client
+		heads[name] = newHeadHistory(headHistorySize)
+		nonces[name] = &nonceCache{}
 	}
 
-	peers := make(map[string]string, 0)
-	for _, peer := range cfg.Monitor.Peers {
-		parts := strings.Split(peer, "=")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid peer config: %s", peer)
-		}
-		label := strings.TrimSpace(parts[0])
-		if len(label) == 0 {
-			return nil, fmt.Errorf("invalid peer label: %s", peer)
-		}
-		ip := net.ParseIP(strings.TrimSpace(parts[1]))
-		if ip == nil {
-			if len(label) == 0 {
-				return nil, fmt.Errorf("invalid peer ip: %s", peer)
-			}
-		}
-		if _, known := peers[ip.String()]; known {
-			if len(label) == 0 {
-				return nil, fmt.Errorf("duplicate ip: %s vs %s",
-					peer, fmt.Sprintf("%s=%s", label, peers[ip.String()]),
-				)
-			}
+	var (
+		reference     *builder
+		referenceName string
+	)
+	if cfg.Monitor.Reference != nil {
+		referenceName = cfg.Monitor.Reference.Name
+		client, err := dialBuilder(context.Background(), cfg.Monitor.Reference, cfg.Monitor.Timeout)
+		if err != nil {
+			return nil, err
 		}
-		peers[ip.String()] = label
+		reference = client
+		nonces[referenceName] = &nonceCache{}
+	}
+
+	peers, err := parsePeers(cfg.Monitor.Peers)
+	if err != nil {
+		return nil, err
 	}
 
 	s := &Server{
-		builders: builders,
-		cfg:      cfg,
-		failure:  make(chan error, 1),
-		logger:   zap.L(),
-		peers:    peers,
-		ticker:   time.NewTicker(cfg.Monitor.Interval),
+		builders:  builders,
+		cfg:       cfg,
+		failure:   make(chan error, 1),
+		heads:     heads,
+		labelKeys: labelKeys(builders, reference),
+		loader:    loader,
+		nonces:    nonces,
+		logger:    zap.L(),
+		peers:     peers,
+		ticker:    time.NewTicker(tickerInterval(cfg.Monitor)),
+		txs:       newTxTracker(),
+
+		reference:     reference,
+		referenceName: referenceName,
+
+		findings:       make(map[string]*types.Finding),
+		findingsSeries: make(map[findingsSeries]struct{}),
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleHealthcheck)
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/api/v1/status", s.handleStatus)
+	mux.HandleFunc("/api/v1/txs/{hash}", s.handleTx)
+	if cfg.Server.AdminToken != "" {
+		mux.HandleFunc("/api/v1/reload", s.handleReload)
+	}
+	mux.Handle("/metrics", metrics.Handler())
 	handler := httplogger.Middleware(s.logger, mux)
 
 	s.server = &http.Server{
@@ -124,9 +149,31 @@ func (s *Server) Run() error {
 		l.Info("Builder monitor server is down")
 	}()
 
+	{ // start websocket subscriptions
+		s.mx.Lock()
+		for _, b := range s.builders {
+			b.start(l)
+		}
+		if s.reference != nil {
+			s.reference.start(l)
+		}
+		s.mx.Unlock()
+	}
+
 	go func() { // run the monitor loop
-		for {
-			s.monitor(ctx, <-s.ticker.C)
+		s.loop(ctx)
+	}()
+
+	go func() { // reload the configuration on sighup
+		reloader := make(chan os.Signal, 1)
+		signal.Notify(reloader, syscall.SIGHUP)
+		for range reloader {
+			l.Info("Reload signal received; reloading configuration...")
+			if err := s.reload(ctx); err != nil {
+				l.Error("Failed to reload configuration",
+					zap.Error(err),
+				)
+			}
 		}
 	}()
 
@@ -175,10 +222,53 @@ func (s *Server) Run() error {
 	}
 
 	{ // close the clients
-		for _, rpc := range s.builders {
-			rpc.Close()
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		for _, b := range s.builders {
+			b.close()
+		}
+		if s.reference != nil {
+			s.reference.close()
 		}
 	}
 
 	return utils.FlattenErrors(errs)
 }
+
+// tickerInterval returns the interval of the ticker that drives monitoring
+// passes (or that polls for new heads with blocks schedule).
+func tickerInterval(cfg *config.Monitor) time.Duration {
+	if cfg.Schedule == config.ScheduleBlocks {
+		return cfg.BlockPollInterval
+	}
+	return cfg.Interval
+}
+
+func parsePeers(cfg []string) (map[string]string, error) {
+	peers := make(map[string]string, 0)
+	for _, peer := range cfg {
+		parts := strings.Split(peer, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid peer config: %s", peer)
+		}
+		label := strings.TrimSpace(parts[0])
+		if len(label) == 0 {
+			return nil, fmt.Errorf("invalid peer label: %s", peer)
+		}
+		ip := net.ParseIP(strings.TrimSpace(parts[1]))
+		if ip == nil {
+			if len(label) == 0 {
+				return nil, fmt.Errorf("invalid peer ip: %s", peer)
+			}
+		}
+		if _, known := peers[ip.String()]; known {
+			if len(label) == 0 {
+				return nil, fmt.Errorf("duplicate ip: %s vs %s",
+					peer, fmt.Sprintf("%s=%s", label, peers[ip.String()]),
+				)
+			}
+		}
+		peers[ip.String()] = label
+	}
+	return peers, nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/config"
+	"github.com/flashbots/bmonitor/metrics"
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestMain(m *testing.M) {
+	if err := metrics.Setup(context.Background()); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestServer returns the server that monitors the builders (which are
+// not dialled).
+func newTestServer(t *testing.T, builders ...string) *Server {
+	t.Helper()
+
+	cfg := config.New()
+	cfg.Monitor.NonceBatchSize = 100
+	cfg.Monitor.NonceWorkers = 4
+	cfg.Monitor.Timeout = time.Second
+
+	s := &Server{
+		builders:       make(map[string]*builder, len(builders)),
+		cfg:            cfg,
+		findings:       make(map[string]*types.Finding),
+		findingsSeries: make(map[findingsSeries]struct{}),
+		heads:          make(map[string]*headHistory, len(builders)),
+		nonces:         make(map[string]*nonceCache, len(builders)),
+		txs:            newTxTracker(),
+	}
+	for _, name := range builders {
+		s.builders[name] = &builder{cfg: &config.Builder{Name: name}}
+		s.heads[name] = newHeadHistory(headHistorySize)
+		s.nonces[name] = &nonceCache{}
+	}
+
+	return s
+}
+
+// serveTestBuilder serves the given json-rpc namespaces over http and dials
+// the builder to it (in place of the one the server has under that name).
+func serveTestBuilder(t *testing.T, s *Server, cfg *config.Builder, namespaces map[string]any) *httptest.Server {
+	t.Helper()
+
+	srv := rpc.NewServer()
+	for name, receiver := range namespaces {
+		if err := srv.RegisterName(name, receiver); err != nil {
+			t.Fatal(err)
+		}
+	}
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	cfg.URL = httpSrv.URL
+	b, err := dialBuilder(context.Background(), cfg, s.cfg.Monitor.Timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.close)
+	s.builders[cfg.Name] = b
+
+	return httpSrv
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+// findStaleTxs returns the finding about the txs from the address that are
+// still in the builder's txpool even though their nonces are below the one
+// the builder has already confirmed (or nil if there are none).
+func findStaleTxs(
+	builder, addr string,
+	confirmed uint64,
+	pending, queued map[string]*jrpc.TxpoolContent_Tx,
+) *types.Finding {
+	type staleTx struct {
+		nonce uint64
+		hash  string
+	}
+
+	stale := make([]staleTx, 0)
+	for _, txs := range []map[string]*jrpc.TxpoolContent_Tx{pending, queued} {
+		for strNonce, tx := range txs {
+			nonce, err := strconv.ParseUint(strNonce, 10, 64)
+			if err != nil || nonce >= confirmed {
+				continue
+			}
+			stale = append(stale, staleTx{nonce: nonce, hash: tx.Hash})
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(stale, func(a, b staleTx) int {
+		if a.nonce != b.nonce {
+			return cmp.Compare(a.nonce, b.nonce)
+		}
+		return strings.Compare(a.hash, b.hash)
+	})
+
+	hashes := make([]string, 0, len(stale))
+	for _, tx := range stale {
+		hashes = append(hashes, tx.hash)
+	}
+
+	return &types.Finding{
+		Kind:     types.FindingStaleTx,
+		Severity: types.SeverityWarning,
+		Message:  "Txs with already confirmed nonces linger in the txpool",
+		Builder:  builder,
+		Address:  addr,
+		Nonces:   &types.NonceRange{Start: stale[0].nonce, End: stale[len(stale)-1].nonce},
+		TxHashes: hashes,
+	}
+}
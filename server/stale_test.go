@@ -0,0 +1,96 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+func TestFindStaleTxs(t *testing.T) {
+	txs := func(hashes map[string]string) map[string]*jrpc.TxpoolContent_Tx {
+		res := make(map[string]*jrpc.TxpoolContent_Tx, len(hashes))
+		for nonce, hash := range hashes {
+			res[nonce] = &jrpc.TxpoolContent_Tx{From: "0xaddr", Nonce: nonce, Hash: hash}
+		}
+		return res
+	}
+
+	for _, tc := range []struct {
+		name      string
+		confirmed uint64
+		pending   map[string]string // nonce -> hash
+		queued    map[string]string // nonce -> hash
+		want      *types.NonceRange
+		wantTxs   []string
+	}{
+		{
+			name:      "empty txpool",
+			confirmed: 5,
+		},
+		{
+			name:      "nothing below confirmed nonce",
+			confirmed: 5,
+			pending:   map[string]string{"5": "0x05", "6": "0x06"},
+			queued:    map[string]string{"8": "0x08"},
+		},
+		{
+			name:      "stale pending txs",
+			confirmed: 5,
+			pending:   map[string]string{"3": "0x03", "4": "0x04", "5": "0x05"},
+			want:      &types.NonceRange{Start: 3, End: 4},
+			wantTxs:   []string{"0x03", "0x04"},
+		},
+		{
+			name:      "stale pending and queued txs are sorted by nonce",
+			confirmed: 10,
+			pending:   map[string]string{"7": "0x07"},
+			queued:    map[string]string{"2": "0x02", "12": "0x12"},
+			want:      &types.NonceRange{Start: 2, End: 7},
+			wantTxs:   []string{"0x02", "0x07"},
+		},
+		{
+			name:      "same nonce in pending and queued",
+			confirmed: 4,
+			pending:   map[string]string{"3": "0x3b"},
+			queued:    map[string]string{"3": "0x3a"},
+			want:      &types.NonceRange{Start: 3, End: 3},
+			wantTxs:   []string{"0x3a", "0x3b"},
+		},
+		{
+			name:      "malformed nonces are ignored",
+			confirmed: 5,
+			pending:   map[string]string{"0x1": "0x01", "2": "0x02"},
+			want:      &types.NonceRange{Start: 2, End: 2},
+			wantTxs:   []string{"0x02"},
+		},
+		{
+			name:      "nothing confirmed yet",
+			confirmed: 0,
+			pending:   map[string]string{"0": "0x00"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findStaleTxs("b0", "0xaddr", tc.confirmed, txs(tc.pending), txs(tc.queued))
+			if tc.want == nil {
+				if got != nil {
+					t.Fatalf("findStaleTxs() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("findStaleTxs() = nil, want nonces %+v", tc.want)
+			}
+			if got.Kind != types.FindingStaleTx || got.Builder != "b0" || got.Address != "0xaddr" {
+				t.Errorf("findStaleTxs() = %+v, want stale tx finding for b0/0xaddr", got)
+			}
+			if !reflect.DeepEqual(got.Nonces, tc.want) {
+				t.Errorf("nonces = %+v, want %+v", got.Nonces, tc.want)
+			}
+			if !reflect.DeepEqual(got.TxHashes, tc.wantTxs) {
+				t.Errorf("tx hashes = %v, want %v", got.TxHashes, tc.wantTxs)
+			}
+		})
+	}
+}
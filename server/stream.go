@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.uber.org/zap"
+)
+
+const (
+	streamBackoffMin = time.Second
+	streamBackoffMax = 30 * time.Second
+
+	// streamMaxBlocks is how many new blocks the txpool view can fall behind
+	// before it is discarded (and the new snapshot is required)
+	streamMaxBlocks = 64
+)
+
+// stream maintains the view of the builder's head and txpool via websocket
+// subscriptions (`newHeads` and `newPendingTransactions`), reconnecting
+// whenever the connection is lost.  The txpool view starts from the snapshot
+// of the txpool, the announced txs are added to it, and the ones included in
+// the new blocks are dropped from it.  As the txs announced while the
+// subscription was down are lost, the view is discarded on disconnect.
+type stream struct {
+	dial  func(context.Context) (*endpoint, error)
+	label string // url of the endpoint without credentials
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mx        sync.Mutex
+	announced map[string]*announcedTx // tx hash -> announced tx
+	connected bool
+	count     int64 // count of txs announced since the last snapshot
+	head      *jrpc.EthBlock
+
+	txpool     *jrpc.TxpoolContent // nil until the snapshot is taken while connected
+	snapshotAt time.Time           // when the snapshot the view is based on was requested
+	blocks     []*streamBlock      // new blocks which txs were not yet dropped from the view
+}
+
+type announcedTx struct {
+	at time.Time
+	tx *jrpc.TxpoolContent_Tx
+}
+
+type streamBlock struct {
+	at   time.Time
+	hash string
+}
+
+var (
+	errStreamDisconnected = errors.New("websocket subscription is down")
+)
+
+func newStream(label string, dial func(context.Context) (*endpoint, error)) *stream {
+	return &stream{
+		announced: make(map[string]*announcedTx),
+		dial:      dial,
+		label:     label,
+	}
+}
+
+func (st *stream) start(l *zap.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	st.cancel = cancel
+	st.done = make(chan struct{})
+
+	go func() {
+		defer close(st.done)
+		st.run(ctx, l)
+	}()
+}
+
+func (st *stream) stop() {
+	if st.cancel == nil {
+		return
+	}
+	st.cancel()
+	<-st.done
+}
+
+func (st *stream) run(ctx context.Context, l *zap.Logger) {
+	backoff := streamBackoffMin
+	for {
+		start := time.Now()
+		err := redactError(st.subscribe(ctx, l))
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > streamBackoffMax {
+			backoff = streamBackoffMin // was connected for a while
+		}
+
+		l.Warn("Builder's websocket subscription has failed, reconnecting...",
+			zap.Error(err),
+			zap.Duration("backoff", backoff),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, streamBackoffMax)
+	}
+}
+
+func (st *stream) subscribe(ctx context.Context, l *zap.Logger) error {
+	e, err := st.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.client.Close()
+
+	heads := make(chan *jrpc.EthBlock, 16)
+	headsSub, err := e.client.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return err
+	}
+	defer headsSub.Unsubscribe()
+
+	txs := make(chan *jrpc.TxpoolContent_Tx, 1024)
+	txsSub, err := e.client.EthSubscribe(ctx, txs, "newPendingTransactions", true)
+	if err != nil {
+		return err
+	}
+	defer txsSub.Unsubscribe()
+
+	l.Info("Subscribed to builder's websocket feeds",
+		zap.String("endpoint", e.label),
+	)
+
+	st.setConnected(true)
+	defer st.setConnected(false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-headsSub.Err():
+			return err
+		case err := <-txsSub.Err():
+			return err
+		case head := <-heads:
+			st.onHead(head, time.Now())
+		case tx := <-txs:
+			st.onTx(tx, time.Now())
+		}
+	}
+}
+
+func (st *stream) setConnected(connected bool) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	st.connected = connected
+	if !connected {
+		st.head = nil
+		st.txpool = nil
+		st.blocks = nil
+	}
+}
+
+// onHead records the new head, and remembers its block so that the txs
+// included in it are dropped from the txpool view later on.  The blocks are
+// remembered even without the view, as they might have been mined while the
+// snapshot was being taken (reset drops the ones that precede it).
+func (st *stream) onHead(head *jrpc.EthBlock, ts time.Time) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	st.head = head
+	if len(st.blocks) == streamMaxBlocks {
+		st.txpool = nil
+		st.blocks = st.blocks[1:]
+	}
+	st.blocks = append(st.blocks, &streamBlock{at: ts, hash: head.Hash})
+}
+
+// onTx records the announced tx and adds it to the txpool view.
+func (st *stream) onTx(tx *jrpc.TxpoolContent_Tx, ts time.Time) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if _, known := st.announced[tx.Hash]; !known {
+		st.announced[tx.Hash] = &announcedTx{at: ts, tx: tx}
+	}
+	st.count++
+	if st.txpool != nil {
+		addPendingTx(st.txpool, tx)
+	}
+}
+
+// reset replaces the txpool view with the snapshot requested at the given
+// time (amended with the txs announced since then, which the snapshot might
+// have missed).  The view is not kept unless the subscription is up.
+func (st *stream) reset(txpool *jrpc.TxpoolContent, requested time.Time) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if !st.connected {
+		st.txpool = nil
+		st.blocks = nil
+		return
+	}
+
+	st.txpool = cloneTxpool(txpool)
+	st.snapshotAt = requested
+	for _, a := range st.announced {
+		if !a.at.Before(requested) {
+			addPendingTx(st.txpool, a.tx)
+		}
+	}
+	st.blocks = slices.DeleteFunc(st.blocks, func(b *streamBlock) bool {
+		return b.at.Before(requested)
+	})
+}
+
+// pendingBlocks returns the hashes of the new blocks which txs are yet to be
+// dropped from the txpool view (see include).  It returns false if there is
+// no view, or if it is based on the snapshot requested before the given time.
+func (st *stream) pendingBlocks(since time.Time) ([]string, bool) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if st.txpool == nil || st.snapshotAt.Before(since) {
+		return nil, false
+	}
+
+	res := make([]string, 0, len(st.blocks))
+	for _, b := range st.blocks {
+		res = append(res, b.hash)
+	}
+	return res, true
+}
+
+// include drops the txs included in the block (as well as the ones they
+// have replaced) from the txpool view.
+func (st *stream) include(hash string, txs []*jrpc.TxpoolContent_Tx) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if st.txpool == nil {
+		return
+	}
+	for _, tx := range txs {
+		dropIncludedTx(st.txpool, tx)
+	}
+	st.blocks = slices.DeleteFunc(st.blocks, func(b *streamBlock) bool {
+		return b.hash == hash
+	})
+}
+
+// view returns the copy of the txpool view (or nil if there is none).
+func (st *stream) view() *jrpc.TxpoolContent {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if st.txpool == nil {
+		return nil
+	}
+	return cloneTxpool(st.txpool)
+}
+
+// latest returns the latest head received via subscription (or nil if the
+// subscription is down).
+func (st *stream) latest() *jrpc.EthBlock {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	return st.head
+}
+
+// snapshot forgets the txs announced before the cutoff and returns the
+// current view of the stream, along with the count of txs that were
+// announced since the previous snapshot.
+func (st *stream) snapshot(cutoff time.Time) (*types.StreamStatus, int64) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	maps.DeleteFunc(st.announced, func(_ string, a *announcedTx) bool {
+		return a.at.Before(cutoff)
+	})
+
+	count := st.count
+	st.count = 0
+
+	announced := make(map[string]time.Time, len(st.announced))
+	for hash, a := range st.announced {
+		announced[hash] = a.at
+	}
+
+	return &types.StreamStatus{
+		Announced: announced,
+		Connected: st.connected,
+		Head:      st.head,
+	}, count
+}
+
+func cloneTxpool(txpool *jrpc.TxpoolContent) *jrpc.TxpoolContent {
+	clone := func(txs map[string]map[string]*jrpc.TxpoolContent_Tx) map[string]map[string]*jrpc.TxpoolContent_Tx {
+		res := make(map[string]map[string]*jrpc.TxpoolContent_Tx, len(txs))
+		for addr, nonces := range txs {
+			res[addr] = maps.Clone(nonces)
+		}
+		return res
+	}
+
+	return &jrpc.TxpoolContent{
+		Pending: clone(txpool.Pending),
+		Queued:  clone(txpool.Queued),
+	}
+}
+
+// txKey returns the keys under which the tx is listed by `txpool_content`:
+// checksummed sender address and decimal nonce.
+func txKey(tx *jrpc.TxpoolContent_Tx) (string, uint64, bool) {
+	nonce, err := hexutil.DecodeUint64(tx.Nonce)
+	if err != nil {
+		return "", 0, false
+	}
+	return common.HexToAddress(tx.From).Hex(), nonce, true
+}
+
+// addPendingTx adds the announced tx to the pending ones (replacing the tx
+// with the same nonce, if any).
+func addPendingTx(txpool *jrpc.TxpoolContent, tx *jrpc.TxpoolContent_Tx) {
+	addr, nonce, ok := txKey(tx)
+	if !ok {
+		return
+	}
+	key := strconv.FormatUint(nonce, 10)
+
+	if queued, known := txpool.Queued[addr]; known {
+		delete(queued, key)
+		if len(queued) == 0 {
+			delete(txpool.Queued, addr)
+		}
+	}
+	if txpool.Pending == nil {
+		txpool.Pending = make(map[string]map[string]*jrpc.TxpoolContent_Tx)
+	}
+	if _, known := txpool.Pending[addr]; !known {
+		txpool.Pending[addr] = make(map[string]*jrpc.TxpoolContent_Tx)
+	}
+	txpool.Pending[addr][key] = tx
+}
+
+// dropIncludedTx drops the txs of the sender of the included tx which nonces
+// are not above its one.
+func dropIncludedTx(txpool *jrpc.TxpoolContent, tx *jrpc.TxpoolContent_Tx) {
+	addr, nonce, ok := txKey(tx)
+	if !ok {
+		return
+	}
+
+	for _, txs := range []map[string]map[string]*jrpc.TxpoolContent_Tx{txpool.Pending, txpool.Queued} {
+		nonces, known := txs[addr]
+		if !known {
+			continue
+		}
+		maps.DeleteFunc(nonces, func(key string, _ *jrpc.TxpoolContent_Tx) bool {
+			n, err := strconv.ParseUint(key, 10, 64)
+			return err == nil && n <= nonce
+		})
+		if len(nonces) == 0 {
+			delete(txs, addr)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/jrpc"
+)
+
+const (
+	testSender = "0x000000000000000000000000000000000000000A"
+	testOther  = "0x000000000000000000000000000000000000000b"
+)
+
+func streamTx(from string, nonce string, hash string) *jrpc.TxpoolContent_Tx {
+	return &jrpc.TxpoolContent_Tx{From: from, Nonce: nonce, Hash: hash}
+}
+
+// streamHashes returns sorted hashes of the txs.
+func streamHashes(txs map[string]map[string]*jrpc.TxpoolContent_Tx) []string {
+	res := make([]string, 0)
+	for _, nonces := range txs {
+		for _, tx := range nonces {
+			res = append(res, tx.Hash)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+func TestStreamTxpoolView(t *testing.T) {
+	var (
+		t0       = time.Unix(1700000000, 0)
+		snapshot = func() *jrpc.TxpoolContent {
+			return &jrpc.TxpoolContent{
+				Pending: map[string]map[string]*jrpc.TxpoolContent_Tx{
+					"0x000000000000000000000000000000000000000A": {
+						"1": streamTx(testSender, "0x1", "p1"),
+						"2": streamTx(testSender, "0x2", "p2"),
+					},
+				},
+				Queued: map[string]map[string]*jrpc.TxpoolContent_Tx{
+					"0x000000000000000000000000000000000000000A": {
+						"4": streamTx(testSender, "0x4", "q4"),
+					},
+				},
+			}
+		}
+	)
+
+	for _, tc := range []struct {
+		name        string
+		run         func(st *stream)
+		since       time.Time // for pending blocks
+		wantView    bool
+		wantPending []string
+		wantQueued  []string
+		wantBlocks  []string
+	}{
+		{
+			name:     "no view before snapshot",
+			run:      func(st *stream) { st.onTx(streamTx(testSender, "0x3", "p3"), t0) },
+			wantView: false,
+		},
+		{
+			name: "no view when snapshot is taken while disconnected",
+			run: func(st *stream) {
+				st.setConnected(false)
+				st.reset(snapshot(), t0)
+			},
+			wantView: false,
+		},
+		{
+			name:        "snapshot",
+			run:         func(st *stream) { st.reset(snapshot(), t0) },
+			wantView:    true,
+			wantPending: []string{"p1", "p2"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "snapshot is amended with txs announced after it was requested",
+			run: func(st *stream) {
+				st.onTx(streamTx(testSender, "0x3", "p3"), t0.Add(-time.Second))
+				st.onTx(streamTx(testOther, "0x0", "o0"), t0.Add(time.Second))
+				st.reset(snapshot(), t0)
+			},
+			wantView:    true,
+			wantPending: []string{"o0", "p1", "p2"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "announced txs are added to pending ones",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.onTx(streamTx(testSender, "0x3", "p3"), t0.Add(time.Second))
+				st.onTx(streamTx(testOther, "0x0", "o0"), t0.Add(time.Second))
+			},
+			wantView:    true,
+			wantPending: []string{"o0", "p1", "p2", "p3"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "announced tx replaces the one with the same nonce",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.onTx(streamTx(testSender, "0x2", "p2'"), t0.Add(time.Second))
+			},
+			wantView:    true,
+			wantPending: []string{"p1", "p2'"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "promoted tx is not queued anymore",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.onTx(streamTx(testSender, "0x3", "p3"), t0.Add(time.Second))
+				st.onTx(streamTx(testSender, "0x4", "q4"), t0.Add(time.Second))
+			},
+			wantView:    true,
+			wantPending: []string{"p1", "p2", "p3", "q4"},
+			wantQueued:  []string{},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "new blocks are pending until included",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.onHead(block(10, "b10", "b9"), t0.Add(time.Second))
+				st.onHead(block(11, "b11", "b10"), t0.Add(2*time.Second))
+				st.include("b10", []*jrpc.TxpoolContent_Tx{streamTx(testSender, "0x1", "p1")})
+			},
+			wantView:    true,
+			wantPending: []string{"p2"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{"b11"},
+		},
+		{
+			name: "included tx drops the lower nonces of the sender too",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.onHead(block(10, "b10", "b9"), t0.Add(time.Second))
+				st.include("b10", []*jrpc.TxpoolContent_Tx{
+					streamTx(testSender, "0x4", "x4"),
+					streamTx(testOther, "0x7", "o7"),
+				})
+			},
+			wantView:    true,
+			wantPending: []string{},
+			wantQueued:  []string{},
+			wantBlocks:  []string{},
+		},
+		{
+			name: "blocks received before the snapshot are not pending",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0.Add(-time.Minute))
+				st.onHead(block(10, "b10", "b9"), t0.Add(-time.Second))
+				st.onHead(block(11, "b11", "b10"), t0.Add(time.Second))
+				st.reset(snapshot(), t0)
+			},
+			wantView:    true,
+			wantPending: []string{"p1", "p2"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{"b11"},
+		},
+		{
+			name: "blocks received while the snapshot is taken are pending",
+			run: func(st *stream) {
+				st.onHead(block(10, "b10", "b9"), t0.Add(-time.Second))
+				st.onHead(block(11, "b11", "b10"), t0.Add(time.Second))
+				st.reset(snapshot(), t0)
+			},
+			wantView:    true,
+			wantPending: []string{"p1", "p2"},
+			wantQueued:  []string{"q4"},
+			wantBlocks:  []string{"b11"},
+		},
+		{
+			name: "view is discarded when it falls too far behind",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				for n := range streamMaxBlocks + 1 {
+					st.onHead(block(uint64(n), hashOf(uint64(n)), ""), t0.Add(time.Second))
+				}
+			},
+			wantView: false,
+		},
+		{
+			name: "view is discarded on disconnect",
+			run: func(st *stream) {
+				st.reset(snapshot(), t0)
+				st.setConnected(false)
+				st.setConnected(true)
+			},
+			wantView: false,
+		},
+		{
+			name:     "view based on outdated snapshot",
+			run:      func(st *stream) { st.reset(snapshot(), t0) },
+			since:    t0.Add(time.Second),
+			wantView: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := newStream("ws://builder", nil)
+			st.setConnected(true)
+			tc.run(st)
+
+			since := tc.since
+			if since.IsZero() {
+				since = t0.Add(-time.Hour)
+			}
+			blocks, ok := st.pendingBlocks(since)
+			if ok != tc.wantView {
+				t.Fatalf("pendingBlocks() ok = %t, want %t", ok, tc.wantView)
+			}
+			if !ok {
+				return
+			}
+			if !slices.Equal(blocks, tc.wantBlocks) {
+				t.Errorf("pendingBlocks() = %v, want %v", blocks, tc.wantBlocks)
+			}
+
+			view := st.view()
+			if got := streamHashes(view.Pending); !slices.Equal(got, tc.wantPending) {
+				t.Errorf("pending = %v, want %v", got, tc.wantPending)
+			}
+			if got := streamHashes(view.Queued); !slices.Equal(got, tc.wantQueued) {
+				t.Errorf("queued = %v, want %v", got, tc.wantQueued)
+			}
+		})
+	}
+}
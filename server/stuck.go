@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/flashbots/bmonitor/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// analyseStuck reports the pending txs that have been known to the builder
+// for longer than the threshold.  The txs which nonces are already confirmed
+// are reported as stale instead.
+func (s *Server) analyseStuck(
+	ctx context.Context,
+	now time.Time,
+	status map[string]*types.BuilderStatus,
+	nonces map[string]map[string]uint64,
+) []*types.Finding {
+	findings := make([]*types.Finding, 0)
+
+	for builder, sts := range status {
+		if sts.Txpool == nil {
+			continue
+		}
+
+		for account, txs := range sts.Txpool.Pending {
+			// the confirmed nonces are keyed by checksummed addresses
+			from := common.HexToAddress(account).Hex()
+			confirmed, knownConfirmed := nonces[builder][from]
+
+			for strNonce, tx := range txs {
+				age := s.txs.ageOn(tx.Hash, builder, now)
+				if s.cfg.Monitor.StuckThreshold == 0 || age <= s.cfg.Monitor.StuckThreshold {
+					continue
+				}
+				nonce, err := strconv.ParseUint(strNonce, 10, 64)
+				if err != nil || (knownConfirmed && nonce < confirmed) {
+					continue
+				}
+
+				findings = append(findings, &types.Finding{
+					Kind:     types.FindingStuckTx,
+					Severity: types.SeverityWarning,
+					Message:  "Tx is pending for too long without being included",
+					Builder:  builder,
+					Address:  from,
+					Nonces:   &types.NonceRange{Start: nonce, End: nonce},
+					TxHashes: []string{tx.Hash},
+				})
+			}
+		}
+	}
+
+	return findings
+}
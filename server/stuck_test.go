@@ -0,0 +1,92 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+func TestAnalyseStuck(t *testing.T) {
+	const (
+		checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		lowercase   = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	)
+
+	type stuck struct {
+		address string
+		nonce   uint64
+	}
+
+	for _, tc := range []struct {
+		name      string
+		account   string // key of the account in txpool_content
+		confirmed map[string]uint64
+		age       time.Duration
+		want      []stuck
+	}{
+		{
+			name:      "confirmed nonces are not stuck",
+			account:   checksummed,
+			confirmed: map[string]uint64{checksummed: 5},
+			age:       time.Hour,
+			want:      []stuck{{checksummed, 5}},
+		},
+		{
+			name:      "lowercase account",
+			account:   lowercase,
+			confirmed: map[string]uint64{checksummed: 5},
+			age:       time.Hour,
+			want:      []stuck{{checksummed, 5}},
+		},
+		{
+			name:    "confirmed nonce is unknown",
+			account: lowercase,
+			age:     time.Hour,
+			want:    []stuck{{checksummed, 4}, {checksummed, 5}},
+		},
+		{
+			name:      "txs are young",
+			account:   checksummed,
+			confirmed: map[string]uint64{checksummed: 5},
+			age:       time.Second,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, "b0")
+			s.cfg.Monitor.StuckThreshold = time.Minute
+
+			status := map[string]*types.BuilderStatus{
+				"b0": {
+					Txpool: &jrpc.TxpoolContent{
+						Pending: map[string]map[string]*jrpc.TxpoolContent_Tx{
+							tc.account: {
+								"4": {From: lowercase, Nonce: "0x4", Hash: "0x04"},
+								"5": {From: lowercase, Nonce: "0x5", Hash: "0x05"},
+							},
+						},
+					},
+				},
+			}
+			now := time.Now()
+			s.txs.observe(now.Add(-tc.age), status)
+
+			findings := s.analyseStuck(context.Background(), now, status, map[string]map[string]uint64{
+				"b0": tc.confirmed,
+			})
+
+			got := make([]stuck, 0, len(findings))
+			for _, f := range findings {
+				got = append(got, stuck{f.Address, f.Nonces.Start})
+			}
+			slices.SortFunc(got, func(a, b stuck) int { return cmp.Compare(a.nonce, b.nonce) })
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("analyseStuck() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
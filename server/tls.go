@@ -0,0 +1,55 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/flashbots/bmonitor/config"
+)
+
+var (
+	errTLSInvalidCA   = errors.New("invalid tls ca bundle")
+	errTLSInvalidCert = errors.New("invalid tls client certificate")
+)
+
+// tlsConfig returns tls config for the connections to the builder.
+func tlsConfig(cfg *config.TLS) (*tls.Config, error) {
+	res := &tls.Config{}
+	if cfg == nil {
+		return res, nil
+	}
+
+	res.MinVersion = cfg.Version()
+	res.ServerName = cfg.ServerName
+
+	if cfg.CA != "" {
+		pem, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w",
+				errTLSInvalidCA, err,
+			)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s: no certificates found",
+				errTLSInvalidCA, cfg.CA,
+			)
+		}
+		res.RootCAs = pool
+	}
+
+	if cfg.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w",
+				errTLSInvalidCert, err,
+			)
+		}
+		res.Certificates = []tls.Certificate{cert}
+	}
+
+	return res, nil
+}
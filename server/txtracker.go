@@ -0,0 +1,188 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flashbots/bmonitor/types"
+)
+
+// txRetention is for how long the tx is remembered after it was last seen in
+// any of the txpools.
+const txRetention = 10 * time.Minute
+
+// txTracker remembers when the txs were first and last seen in the txpools of
+// the builders across the monitoring passes.
+type txTracker struct {
+	mx sync.Mutex // guards the state below (which is also read by the api)
+
+	builders map[string]struct{} // builders which txpools were observed in the previous pass
+	txs      map[string]*trackedTx
+}
+
+type trackedTx struct {
+	firstSeen time.Time // when the tx was first seen by any builder
+	lastSeen  time.Time // when the tx was last seen by any builder
+	seenBy    map[string]*txSighting
+}
+
+type txSighting struct {
+	firstSeen    time.Time
+	lastSeen     time.Time
+	pendingSince time.Time // when the tx was first seen pending (zero if it was not, or if it has left the txpool since)
+}
+
+func newTxTracker() *txTracker {
+	return &txTracker{
+		builders: make(map[string]struct{}),
+		txs:      make(map[string]*trackedTx),
+	}
+}
+
+// observe updates the tracker with the txpools of the monitoring pass, and
+// returns the propagation delays (builder -> delays) of the txs that the
+// builders have received since the previous pass after they were seen
+// elsewhere, as well as the ages (builder -> ages) of the pending txs that
+// have left the txpools since the previous pass (so that each tx is counted
+// once).  The builders which txpools were not observed in the previous pass
+// are not reported, as we can not tell when they have received the txs.  The
+// txs that were not seen in any of the txpools for a while are forgotten.
+func (t *txTracker) observe(ts time.Time, status map[string]*types.BuilderStatus) (delays, ages map[string][]time.Duration) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	builders := make(map[string]struct{}, len(status))
+	delays = make(map[string][]time.Duration)
+	ages = make(map[string][]time.Duration)
+
+	see := func(builder, hash string, pending bool) {
+		tx, known := t.txs[hash]
+		if !known {
+			tx = &trackedTx{
+				firstSeen: ts,
+				seenBy:    make(map[string]*txSighting),
+			}
+			t.txs[hash] = tx
+		}
+		tx.lastSeen = ts
+
+		sighting, known := tx.seenBy[builder]
+		if !known {
+			sighting = &txSighting{firstSeen: ts}
+			tx.seenBy[builder] = sighting
+		}
+		sighting.lastSeen = ts
+		if pending && sighting.pendingSince.IsZero() {
+			sighting.pendingSince = ts
+		}
+		if known {
+			return
+		}
+
+		if _, observed := t.builders[builder]; observed && tx.firstSeen.Before(ts) {
+			delays[builder] = append(delays[builder], ts.Sub(tx.firstSeen))
+		}
+	}
+
+	for builder, sts := range status {
+		if sts.Txpool == nil {
+			continue
+		}
+		builders[builder] = struct{}{}
+		for _, nonces := range sts.Txpool.Pending {
+			for _, tx := range nonces {
+				see(builder, tx.Hash, true)
+			}
+		}
+		for _, nonces := range sts.Txpool.Queued {
+			for _, tx := range nonces {
+				see(builder, tx.Hash, false)
+			}
+		}
+	}
+
+	if len(builders) == 0 {
+		return delays, ages // nothing was observed, keep the state until next time
+	}
+
+	for hash, tx := range t.txs {
+		for builder, sighting := range tx.seenBy {
+			if _, observed := builders[builder]; !observed || sighting.lastSeen.Equal(ts) || sighting.pendingSince.IsZero() {
+				continue
+			}
+			// the tx has left the txpool of the builder
+			ages[builder] = append(ages[builder], sighting.lastSeen.Sub(sighting.pendingSince))
+			sighting.pendingSince = time.Time{}
+		}
+		if ts.Sub(tx.lastSeen) > txRetention {
+			delete(t.txs, hash)
+		}
+	}
+	t.builders = builders
+
+	return delays, ages
+}
+
+// forget drops what is known about the txs seen by the builder.
+func (t *txTracker) forget(builder string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	delete(t.builders, builder)
+	for hash, tx := range t.txs {
+		delete(tx.seenBy, builder)
+		if len(tx.seenBy) == 0 {
+			delete(t.txs, hash)
+		}
+	}
+}
+
+// age returns for how long the tx has been known to any of the builders.
+func (t *txTracker) age(hash string, ts time.Time) time.Duration {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if tx, known := t.txs[hash]; known {
+		return ts.Sub(tx.firstSeen)
+	}
+	return 0
+}
+
+// ageOn returns for how long the tx has been known to the builder.
+func (t *txTracker) ageOn(hash, builder string, ts time.Time) time.Duration {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if tx, known := t.txs[hash]; known {
+		if sighting, known := tx.seenBy[builder]; known {
+			return ts.Sub(sighting.firstSeen)
+		}
+	}
+	return 0
+}
+
+// report returns what is known about the tx (or nil if it is not tracked).
+func (t *txTracker) report(hash string) *types.TxReport {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	tx, known := t.txs[hash]
+	if !known {
+		return nil
+	}
+
+	res := &types.TxReport{
+		Hash:      hash,
+		FirstSeen: tx.firstSeen,
+		LastSeen:  tx.lastSeen,
+		Builders:  make(map[string]*types.TxSightingReport, len(tx.seenBy)),
+	}
+	for builder, sighting := range tx.seenBy {
+		res.Builders[builder] = &types.TxSightingReport{
+			FirstSeen: sighting.firstSeen,
+			LastSeen:  sighting.lastSeen,
+		}
+	}
+
+	return res
+}
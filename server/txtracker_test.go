@@ -0,0 +1,267 @@
+package server
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/flashbots/bmonitor/jrpc"
+	"github.com/flashbots/bmonitor/types"
+)
+
+// txStep is the monitoring pass as seen by the tx tracker.
+type txStep struct {
+	at      time.Duration
+	pending map[string][]string // builder -> hashes of pending txs
+	queued  map[string][]string // builder -> hashes of queued txs
+	down    []string            // builders which txpools could not be fetched
+
+	wantDelays map[string][]time.Duration
+	wantAges   map[string][]time.Duration
+}
+
+// status returns the status of the builders as of the step.
+func (step *txStep) status() map[string]*types.BuilderStatus {
+	txs := func(hashes []string) map[string]map[string]*jrpc.TxpoolContent_Tx {
+		res := map[string]map[string]*jrpc.TxpoolContent_Tx{"0xaddr": {}}
+		for idx, hash := range hashes {
+			nonce := strconv.Itoa(idx)
+			res["0xaddr"][nonce] = &jrpc.TxpoolContent_Tx{From: "0xaddr", Nonce: nonce, Hash: hash}
+		}
+		return res
+	}
+
+	res := make(map[string]*types.BuilderStatus)
+	for builder := range maps.Keys(step.pending) {
+		res[builder] = &types.BuilderStatus{Txpool: &jrpc.TxpoolContent{}}
+	}
+	for builder := range maps.Keys(step.queued) {
+		res[builder] = &types.BuilderStatus{Txpool: &jrpc.TxpoolContent{}}
+	}
+	for builder, sts := range res {
+		sts.Txpool.Pending = txs(step.pending[builder])
+		sts.Txpool.Queued = txs(step.queued[builder])
+	}
+	for _, builder := range step.down {
+		res[builder] = &types.BuilderStatus{}
+	}
+	return res
+}
+
+// runTxSteps feeds the steps into the tracker and verifies what it reports.
+func runTxSteps(t *testing.T, tracker *txTracker, start time.Time, steps []txStep) {
+	t.Helper()
+
+	equal := func(got, want map[string][]time.Duration) bool {
+		for _, durations := range got {
+			slices.Sort(durations)
+		}
+		return maps.EqualFunc(got, want, slices.Equal)
+	}
+
+	for idx, step := range steps {
+		delays, ages := tracker.observe(start.Add(step.at), step.status())
+		if step.wantDelays == nil {
+			step.wantDelays = map[string][]time.Duration{}
+		}
+		if step.wantAges == nil {
+			step.wantAges = map[string][]time.Duration{}
+		}
+		if !equal(delays, step.wantDelays) {
+			t.Errorf("pass %d: delays = %v, want %v", idx, delays, step.wantDelays)
+		}
+		if !equal(ages, step.wantAges) {
+			t.Errorf("pass %d: ages = %v, want %v", idx, ages, step.wantAges)
+		}
+	}
+}
+
+func TestTxTrackerDelays(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps []txStep
+	}{
+		{
+			name: "txs of the first pass have no delay",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": {"t1"}}},
+			},
+		},
+		{
+			name: "tx received later",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": nil}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}},
+					wantDelays: map[string][]time.Duration{"b": {5 * time.Second}}},
+			},
+		},
+		{
+			name: "queued txs are counted too",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}}, queued: map[string][]string{"b": nil}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}}, queued: map[string][]string{"b": {"t1"}},
+					wantDelays: map[string][]time.Duration{"b": {5 * time.Second}}},
+			},
+		},
+		{
+			name: "delay is reported once",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": nil}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}},
+					wantDelays: map[string][]time.Duration{"b": {5 * time.Second}}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}}},
+			},
+		},
+		{
+			name: "builder not observed in the previous pass is not reported",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}}, down: []string{"b"}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}}},
+			},
+		},
+		{
+			name: "pass without txpools keeps the state",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": nil}},
+				{at: 5 * time.Second, down: []string{"a", "b"}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}},
+					wantDelays: map[string][]time.Duration{"b": {10 * time.Second}}},
+			},
+		},
+		{
+			name: "delays are measured from the first sighting anywhere",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": nil, "c": nil}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1", "t2"}, "b": {"t1"}, "c": nil},
+					wantDelays: map[string][]time.Duration{"b": {5 * time.Second}}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": {"t1", "t2"}, "b": {"t1", "t2"}, "c": {"t1", "t2"}},
+					wantDelays: map[string][]time.Duration{
+						"b": {10 * time.Second},
+						"c": {10 * time.Second, 15 * time.Second},
+					}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runTxSteps(t, newTxTracker(), time.Unix(1700000000, 0), tc.steps)
+		})
+	}
+}
+
+func TestTxTrackerAges(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps []txStep
+	}{
+		{
+			name: "age is observed once, when tx leaves the txpool",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": nil},
+					wantAges: map[string][]time.Duration{"a": {5 * time.Second}}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": nil}},
+			},
+		},
+		{
+			name: "queued txs have no age",
+			steps: []txStep{
+				{queued: map[string][]string{"a": {"t1"}}},
+				{at: 5 * time.Second, queued: map[string][]string{"a": {"t1"}}},
+				{at: 10 * time.Second, queued: map[string][]string{"a": nil}},
+			},
+		},
+		{
+			name: "age counts from when tx became pending",
+			steps: []txStep{
+				{queued: map[string][]string{"a": {"t1"}}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": nil},
+					wantAges: map[string][]time.Duration{"a": {5 * time.Second}}},
+			},
+		},
+		{
+			name: "ages are observed per builder",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}, "b": nil}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": {"t1"}},
+					wantDelays: map[string][]time.Duration{"b": {5 * time.Second}}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": {"t1"}, "b": nil},
+					wantAges: map[string][]time.Duration{"b": {0}}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": nil, "b": nil},
+					wantAges: map[string][]time.Duration{"a": {10 * time.Second}}},
+			},
+		},
+		{
+			name: "builder that was not observed is reported later",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 10 * time.Second, down: []string{"a"}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": nil},
+					wantAges: map[string][]time.Duration{"a": {5 * time.Second}}},
+			},
+		},
+		{
+			name: "tx that comes back is aged anew",
+			steps: []txStep{
+				{pending: map[string][]string{"a": {"t1"}}},
+				{at: 5 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 10 * time.Second, pending: map[string][]string{"a": nil},
+					wantAges: map[string][]time.Duration{"a": {5 * time.Second}}},
+				{at: 15 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 25 * time.Second, pending: map[string][]string{"a": {"t1"}}},
+				{at: 30 * time.Second, pending: map[string][]string{"a": nil},
+					wantAges: map[string][]time.Duration{"a": {10 * time.Second}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runTxSteps(t, newTxTracker(), time.Unix(1700000000, 0), tc.steps)
+		})
+	}
+}
+
+func TestTxTrackerRetention(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	tracker := newTxTracker()
+	runTxSteps(t, tracker, start, []txStep{
+		{pending: map[string][]string{"a": {"t1", "t2"}, "b": {"t2"}}},
+		{at: time.Minute, pending: map[string][]string{"a": {"t2"}, "b": {"t2"}},
+			wantAges: map[string][]time.Duration{"a": {0}}},
+		{at: txRetention, pending: map[string][]string{"a": {"t2"}, "b": {"t2"}}},
+	})
+	if tracker.report("t1") == nil {
+		t.Errorf("t1 is forgotten before its retention is over")
+	}
+
+	runTxSteps(t, tracker, start, []txStep{
+		{at: txRetention + 2*time.Minute, pending: map[string][]string{"a": {"t2"}, "b": {"t2"}}},
+	})
+	if tracker.report("t1") != nil {
+		t.Errorf("t1 is remembered after its retention is over")
+	}
+	if got, want := tracker.age("t2", start.Add(txRetention+2*time.Minute)), txRetention+2*time.Minute; got != want {
+		t.Errorf("age of t2 = %s, want %s", got, want)
+	}
+
+	// the forgotten tx is a new one if it is seen again
+	runTxSteps(t, tracker, start, []txStep{
+		{at: txRetention + 3*time.Minute, pending: map[string][]string{"a": {"t2"}, "b": {"t1", "t2"}}},
+	})
+	if got := tracker.ageOn("t1", "b", start.Add(txRetention+4*time.Minute)); got != time.Minute {
+		t.Errorf("age of t1 on b = %s, want %s", got, time.Minute)
+	}
+
+	tracker.forget("b")
+	if report := tracker.report("t1"); report != nil {
+		t.Errorf("t1 seen by forgotten builder only is remembered: %+v", report)
+	}
+	if report := tracker.report("t2"); report == nil || len(report.Builders) != 1 || report.Builders["a"] == nil {
+		t.Errorf("t2 after b is forgotten = %+v, want seen by a only", report)
+	}
+}
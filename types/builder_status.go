@@ -1,9 +1,38 @@
 package types
 
-import "github.com/flashbots/bmonitor/jrpc"
+import (
+	"time"
+
+	"github.com/flashbots/bmonitor/jrpc"
+)
 
 type BuilderStatus struct {
-	Peers  *jrpc.AdminPeers
-	Txpool *jrpc.TxpoolContent
-	Err    error
+	Block      uint64 // head number at which the snapshot was taken (0 if unknown)
+	Head       *jrpc.EthBlock
+	HeadSource string // endpoint that reported the head
+	Peers      *jrpc.AdminPeers
+	Txpool     *jrpc.TxpoolContent
+	Stream     *StreamStatus
+	Err        error
+	Down       bool // some rpc call failed to reach the builder (as opposed to being rejected by it)
+}
+
+// StreamStatus is the view of the builder maintained via websocket
+// subscriptions in between the snapshots.  The announced txs are only those
+// that the txpool snapshot might have missed (i.e. announced after it was
+// requested).
+type StreamStatus struct {
+	Announced map[string]time.Time // tx hash -> when it was announced
+	Connected bool
+	Head      *jrpc.EthBlock
+}
+
+// IsAnnounced returns true if the builder has announced the tx via
+// subscription after its txpool snapshot was requested.
+func (s *StreamStatus) IsAnnounced(hash string) bool {
+	if s == nil {
+		return false
+	}
+	_, announced := s.Announced[hash]
+	return announced
 }
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FindingKind string
+
+const (
+	FindingChainFork             FindingKind = "chain_fork"
+	FindingDuplicateNonce        FindingKind = "duplicate_nonce"
+	FindingIncludedTx            FindingKind = "included_tx"
+	FindingMissingTx             FindingKind = "missing_tx"
+	FindingNonceGap              FindingKind = "nonce_gap"
+	FindingNonceMismatch         FindingKind = "nonce_mismatch"
+	FindingPendingAndQueued      FindingKind = "pending_and_queued"
+	FindingPendingQueuedMismatch FindingKind = "pending_queued_mismatch"
+	FindingReorg                 FindingKind = "reorg"
+	FindingReplacedTx            FindingKind = "replaced_tx"
+	FindingSkewedSnapshot        FindingKind = "skewed_snapshot"
+	FindingStaleTx               FindingKind = "stale_tx"
+	FindingStuckTx               FindingKind = "stuck_tx"
+	FindingUnknownTx             FindingKind = "unknown_tx"
+)
+
+type FindingSeverity string
+
+const (
+	SeverityDebug   FindingSeverity = "debug"
+	SeverityInfo    FindingSeverity = "info"
+	SeverityWarning FindingSeverity = "warning"
+	SeverityError   FindingSeverity = "error"
+)
+
+// Finding is an anomaly detected by one of the analysers.
+type Finding struct {
+	Kind     FindingKind     `json:"kind"`
+	Severity FindingSeverity `json:"severity"`
+	Message  string          `json:"message"`
+
+	Builder     string            `json:"builder,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Other       string            `json:"other,omitempty"` // other builder (for the findings about the pairs of builders)
+	Address     string            `json:"address,omitempty"`
+	Nonces      *NonceRange       `json:"nonces,omitempty"`
+	TxHashes    []string          `json:"tx_hashes,omitempty"`
+	BlockNumber uint64            `json:"block_number,omitempty"`
+	BlockHashes []string          `json:"block_hashes,omitempty"`
+	Depth       int               `json:"depth,omitempty"`
+
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// NonceRange is an inclusive range of account nonces.
+type NonceRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+func (r *NonceRange) Length() uint64 {
+	return r.End - r.Start + 1
+}
+
+// ID identifies the finding across the monitoring passes, so that we can
+// tell when it was first seen.
+func (f *Finding) ID() string {
+	parts := []string{
+		string(f.Kind),
+		f.Builder,
+		f.Other,
+		f.Address,
+		strings.Join(f.TxHashes, ","),
+		strconv.FormatUint(f.BlockNumber, 10),
+		strings.Join(f.BlockHashes, ","),
+	}
+	if f.Nonces != nil {
+		parts = append(parts,
+			strconv.FormatUint(f.Nonces.Start, 10),
+			strconv.FormatUint(f.Nonces.End, 10),
+		)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,82 @@
+package types
+
+import "time"
+
+// Report is the outcome of the monitoring pass.
+type Report struct {
+	Timestamp  time.Time                               `json:"timestamp"`
+	Builders   map[string]*BuilderReport               `json:"builders"`
+	Divergence map[string]map[string]*DivergenceReport `json:"divergence"` // builder -> other builder -> divergence
+	Findings   []*Finding                              `json:"findings"`
+}
+
+type BuilderReport struct {
+	Reachable bool              `json:"reachable"`
+	Error     string            `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+
+	Head   *HeadReport   `json:"head,omitempty"`
+	Peers  *PeersReport  `json:"peers,omitempty"`
+	Txpool *TxpoolReport `json:"txpool,omitempty"`
+	Stream *StreamReport `json:"stream,omitempty"`
+}
+
+// DivergenceReport compares the builder's txpool to the one of other builder.
+type DivergenceReport struct {
+	MissingTxs int     `json:"missing_txs"` // count of other's txs absent from the builder's txpool
+	Similarity float64 `json:"similarity"`  // jaccard index of the two txpools
+}
+
+type HeadReport struct {
+	Number     uint64 `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parent_hash"`
+	Timestamp  uint64 `json:"timestamp"`
+}
+
+type PeersReport struct {
+	Loopback int64            `json:"loopback"`
+	Internal int64            `json:"internal"`
+	External int64            `json:"external"`
+	Labelled map[string]int64 `json:"labelled"`
+}
+
+type StreamReport struct {
+	Connected    bool `json:"connected"`
+	AnnouncedTxs int  `json:"announced_txs"`
+}
+
+type TxpoolReport struct {
+	Pending     int                `json:"pending"`
+	Queued      int                `json:"queued"`
+	NonceGaps   []*NonceGapReport  `json:"nonce_gaps"`
+	MissingTxs  []*MissingTxReport `json:"missing_txs"`
+	IncludedTxs int                `json:"included_txs"`
+	ReplacedTxs int                `json:"replaced_txs"`
+}
+
+type NonceGapReport struct {
+	Address string `json:"address"`
+	Start   uint64 `json:"start"`
+	End     uint64 `json:"end"`
+}
+
+type MissingTxReport struct {
+	Address string `json:"address"`
+	Nonce   uint64 `json:"nonce"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// TxReport is what is known about the tx across the monitoring passes.
+type TxReport struct {
+	Hash      string                       `json:"hash"`
+	FirstSeen time.Time                    `json:"first_seen"`
+	LastSeen  time.Time                    `json:"last_seen"`
+	Builders  map[string]*TxSightingReport `json:"builders"`
+}
+
+// TxSightingReport is when the tx was seen in the txpool of the builder.
+type TxSightingReport struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
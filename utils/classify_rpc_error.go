@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	RPCErrorAuthFailure       = "auth_failure"
+	RPCErrorConnectionRefused = "connection_refused"
+	RPCErrorDecodeError       = "decode_error"
+	RPCErrorMethodNotFound    = "method_not_found"
+	RPCErrorOther             = "other"
+	RPCErrorTimeout           = "timeout"
+)
+
+// ClassifyRPCError returns the reason of the rpc call failure suitable for
+// use as a metric label.
+func ClassifyRPCError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var (
+		httpErr       rpc.HTTPError
+		jsonSyntaxErr *json.SyntaxError
+		jsonTypeErr   *json.UnmarshalTypeError
+		netErr        net.Error
+		rpcErr        rpc.Error
+	)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return RPCErrorTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return RPCErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return RPCErrorConnectionRefused
+	case errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden):
+		return RPCErrorAuthFailure
+	case errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601:
+		return RPCErrorMethodNotFound
+	case errors.As(err, &jsonSyntaxErr), errors.As(err, &jsonTypeErr):
+		return RPCErrorDecodeError
+	}
+
+	return RPCErrorOther
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testRPCError struct {
+	code int
+}
+
+func (e *testRPCError) Error() string  { return fmt.Sprintf("rpc error %d", e.code) }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+type testTimeoutError struct{}
+
+func (testTimeoutError) Error() string   { return "i/o timeout" }
+func (testTimeoutError) Timeout() bool   { return true }
+func (testTimeoutError) Temporary() bool { return true }
+
+func TestClassifyRPCError(t *testing.T) {
+	var (
+		syntaxErr = json.Unmarshal([]byte("{"), new(map[string]any))
+		typeErr   = json.Unmarshal([]byte(`"str"`), new(int))
+	)
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "no error",
+			err:  nil,
+			want: "",
+		},
+		{
+			name: "context deadline",
+			err:  fmt.Errorf("call: %w", context.DeadlineExceeded),
+			want: RPCErrorTimeout,
+		},
+		{
+			name: "network timeout",
+			err:  &url.Error{Op: "Post", URL: "http://127.0.0.1:8545", Err: testTimeoutError{}},
+			want: RPCErrorTimeout,
+		},
+		{
+			name: "connection refused",
+			err: &url.Error{Op: "Post", URL: "http://127.0.0.1:8545", Err: &net.OpError{
+				Op:  "dial",
+				Net: "tcp",
+				Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED},
+			}},
+			want: RPCErrorConnectionRefused,
+		},
+		{
+			name: "unauthorized",
+			err:  rpc.HTTPError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"},
+			want: RPCErrorAuthFailure,
+		},
+		{
+			name: "forbidden",
+			err:  rpc.HTTPError{StatusCode: http.StatusForbidden, Status: "403 Forbidden"},
+			want: RPCErrorAuthFailure,
+		},
+		{
+			name: "other http status",
+			err:  rpc.HTTPError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"},
+			want: RPCErrorOther,
+		},
+		{
+			name: "method not found",
+			err:  &testRPCError{code: -32601},
+			want: RPCErrorMethodNotFound,
+		},
+		{
+			name: "other json-rpc error",
+			err:  &testRPCError{code: -32000},
+			want: RPCErrorOther,
+		},
+		{
+			name: "malformed json",
+			err:  syntaxErr,
+			want: RPCErrorDecodeError,
+		},
+		{
+			name: "unexpected json type",
+			err:  fmt.Errorf("decode: %w", typeErr),
+			want: RPCErrorDecodeError,
+		},
+		{
+			name: "unknown",
+			err:  errors.New("something went wrong"),
+			want: RPCErrorOther,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassifyRPCError(tc.err); got != tc.want {
+				t.Errorf("ClassifyRPCError(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}